@@ -0,0 +1,248 @@
+// Package dag provides utilities to work with the DAG formed by the blocks of a DOUBL ledger, in
+// which every block references its parents by BlockHeader.PrevHashes.
+package dag
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+
+	m "github.com/daotl/go-doubl/model"
+)
+
+var (
+	ErrHeightMismatch = errors.New("block height mismatch")
+	ErrCycle          = errors.New("cycle detected in block headers")
+)
+
+// Parents returns the parent block headers of the block with hash `hash` in the order of
+// BlockHeader.PrevHashes.
+func Parents(l Lookup, hash m.BlockHash) ([]*m.BlockHeaderExt, error) {
+	bhx, err := l.Header(hash)
+	if err != nil {
+		return nil, err
+	}
+	parents := make([]*m.BlockHeaderExt, len(bhx.PrevHashes))
+	for i, ph := range bhx.PrevHashes {
+		if parents[i], err = l.Header(ph); err != nil {
+			return nil, err
+		}
+	}
+	return parents, nil
+}
+
+// Children returns the child block headers of the block with hash `hash`, sorted in ascending
+// order of the hash bytes.
+func Children(l Lookup, hash m.BlockHash) ([]*m.BlockHeaderExt, error) {
+	hs, err := l.Children(hash)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]*m.BlockHeaderExt, len(hs))
+	for i, h := range hs {
+		if children[i], err = l.Header(h); err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
+}
+
+// IsAncestor reports whether the block with hash `ancestor` is a proper ancestor of the block with
+// hash `hash`. Blocks with Height not greater than the ancestor's are not traversed, so the
+// heights should be consistent, see CheckHeight.
+func IsAncestor(l Lookup, ancestor, hash m.BlockHash) (bool, error) {
+	a, err := l.Header(ancestor)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	err = walkAncestors(l, hash, func(bhx *m.BlockHeaderExt) (bool, error) {
+		if bytes.Equal(bhx.Hash, hash) {
+			return true, nil
+		}
+		if bytes.Equal(bhx.Hash, ancestor) {
+			found = true
+			return false, errStop
+		}
+		return bhx.Height > a.Height, nil
+	})
+	if err != nil && err != errStop {
+		return false, err
+	}
+	return found, nil
+}
+
+// LowestCommonAncestors returns the lowest common ancestors of the blocks with hashes `a` and `b`,
+// which are the common ancestors (a block is considered an ancestor of itself here) that are not
+// ancestors of any other common ancestor. The result is sorted in ascending order of the hash
+// bytes, it's empty if there is no common ancestor.
+func LowestCommonAncestors(l Lookup, a, b m.BlockHash) ([]m.BlockHash, error) {
+	ancestorsA := make(map[string]struct{})
+	err := walkAncestors(l, a, func(bhx *m.BlockHeaderExt) (bool, error) {
+		ancestorsA[string(bhx.Hash)] = struct{}{}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The common ancestors are closed under taking parents, so the lowest ones are those
+	// that are not parents of any common ancestor.
+	common := make(map[string]m.BlockHash)
+	notLowest := make(map[string]struct{})
+	err = walkAncestors(l, b, func(bhx *m.BlockHeaderExt) (bool, error) {
+		if _, ok := ancestorsA[string(bhx.Hash)]; !ok {
+			return true, nil
+		}
+		common[string(bhx.Hash)] = bhx.Hash
+		for _, ph := range bhx.PrevHashes {
+			notLowest[string(ph)] = struct{}{}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lcas []m.BlockHash
+	for k, h := range common {
+		if _, ok := notLowest[k]; !ok {
+			lcas = append(lcas, h)
+		}
+	}
+	sortHashes(lcas)
+	return lcas, nil
+}
+
+// Tips returns the hashes of the block headers in `bhxs` that are not parents of any other one in
+// `bhxs`, sorted in ascending order of the hash bytes.
+func Tips(bhxs []*m.BlockHeaderExt) []m.BlockHash {
+	parents := make(map[string]struct{})
+	for _, bhx := range bhxs {
+		for _, ph := range bhx.PrevHashes {
+			parents[string(ph)] = struct{}{}
+		}
+	}
+	var tips []m.BlockHash
+	seen := make(map[string]struct{}, len(bhxs))
+	for _, bhx := range bhxs {
+		if _, ok := parents[string(bhx.Hash)]; ok {
+			continue
+		}
+		if _, ok := seen[string(bhx.Hash)]; !ok {
+			seen[string(bhx.Hash)] = struct{}{}
+			tips = append(tips, bhx.Hash)
+		}
+	}
+	sortHashes(tips)
+	return tips
+}
+
+// TopoSort sorts the block headers in `bhxs` in a deterministic topological order, in which every
+// block comes after its parents. Among the blocks whose parents are all sorted, the one with the
+// smallest hash bytes comes first. Parents not in `bhxs` are ignored, and duplicate blocks are
+// only included once. It returns ErrCycle if the block headers form a cycle.
+func TopoSort(bhxs []*m.BlockHeaderExt) ([]*m.BlockHeaderExt, error) {
+	nodes := make(map[string]*m.BlockHeaderExt, len(bhxs))
+	for _, bhx := range bhxs {
+		nodes[string(bhx.Hash)] = bhx
+	}
+	pending := make(map[string]int, len(nodes))
+	children := make(map[string][]*m.BlockHeaderExt, len(nodes))
+	ready := make(hashHeap, 0, len(nodes))
+	for k, bhx := range nodes {
+		parents := make(map[string]struct{}, len(bhx.PrevHashes))
+		for _, ph := range bhx.PrevHashes {
+			if _, ok := nodes[string(ph)]; ok {
+				parents[string(ph)] = struct{}{}
+			}
+		}
+		for ph := range parents {
+			children[ph] = append(children[ph], bhx)
+		}
+		if pending[k] = len(parents); pending[k] == 0 {
+			ready = append(ready, bhx)
+		}
+	}
+	heap.Init(&ready)
+
+	sorted := make([]*m.BlockHeaderExt, 0, len(nodes))
+	for ready.Len() > 0 {
+		bhx := heap.Pop(&ready).(*m.BlockHeaderExt)
+		sorted = append(sorted, bhx)
+		for _, c := range children[string(bhx.Hash)] {
+			if pending[string(c.Hash)]--; pending[string(c.Hash)] == 0 {
+				heap.Push(&ready, c)
+			}
+		}
+	}
+	if len(sorted) != len(nodes) {
+		return nil, fmt.Errorf("%w: %d blocks not sorted", ErrCycle, len(nodes)-len(sorted))
+	}
+	return sorted, nil
+}
+
+// CheckHeight checks that the Height of `bhx` equals the maximum Height of its parents plus 1,
+// or 0 if it has no parent. It returns ErrHeightMismatch if not.
+func CheckHeight(l Lookup, bhx *m.BlockHeaderExt) error {
+	var expected m.BlockHeight
+	for i, ph := range bhx.PrevHashes {
+		p, err := l.Header(ph)
+		if err != nil {
+			return err
+		}
+		if i == 0 || p.Height+1 > expected {
+			expected = p.Height + 1
+		}
+	}
+	if bhx.Height != expected {
+		return fmt.Errorf("%w: block %x has height %d, expected %d",
+			ErrHeightMismatch, bhx.Hash, bhx.Height, expected)
+	}
+	return nil
+}
+
+var errStop = errors.New("stop walking")
+
+// walkAncestors visits the block with hash `hash` and its ancestors in breadth-first order, every
+// block is visited only once. The parents of a block are not visited if `visit` returns false,
+// and walking stops if `visit` returns an error.
+func walkAncestors(l Lookup, hash m.BlockHash, visit func(bhx *m.BlockHeaderExt) (bool, error)) error {
+	visited := map[string]struct{}{string(hash): {}}
+	queue := []m.BlockHash{hash}
+	for len(queue) > 0 {
+		bhx, err := l.Header(queue[0])
+		if err != nil {
+			return err
+		}
+		queue = queue[1:]
+		cont, err := visit(bhx)
+		if err != nil {
+			return err
+		} else if !cont {
+			continue
+		}
+		for _, ph := range bhx.PrevHashes {
+			if _, ok := visited[string(ph)]; !ok {
+				visited[string(ph)] = struct{}{}
+				queue = append(queue, ph)
+			}
+		}
+	}
+	return nil
+}
+
+// hashHeap is a min-heap of block headers ordered by the hash bytes.
+type hashHeap []*m.BlockHeaderExt
+
+func (h hashHeap) Len() int            { return len(h) }
+func (h hashHeap) Less(i, j int) bool  { return bytes.Compare(h[i].Hash, h[j].Hash) < 0 }
+func (h hashHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hashHeap) Push(x interface{}) { *h = append(*h, x.(*m.BlockHeaderExt)) }
+func (h *hashHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,221 @@
+package dag_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/dag"
+	m "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+// genHeader creates a BlockHeaderExt for test with the given parents and consistent height.
+func genHeader(parents ...*m.BlockHeaderExt) *m.BlockHeaderExt {
+	bh := test.GenTestBlockHeaderWithExtra(nil)
+	bh.TxRoot = test.GenRandomHash()
+	bh.PrevHashes = nil
+	bh.Height = 0
+	for _, p := range parents {
+		bh.PrevHashes = append(bh.PrevHashes, p.Hash)
+		if p.Height+1 > bh.Height {
+			bh.Height = p.Height + 1
+		}
+	}
+	bhx, err := test.Util.ExtendBlockHeader(bh)
+	if err != nil {
+		panic(err)
+	}
+	return bhx
+}
+
+// testDAG has the parent relations: a -> g, b -> g, c -> (a, b), d -> a, e -> (c, d),
+// and x is another root.
+type testDAG struct {
+	g, a, b, c, d, e, x *m.BlockHeaderExt
+}
+
+func (d *testDAG) all() []*m.BlockHeaderExt {
+	return []*m.BlockHeaderExt{d.g, d.a, d.b, d.c, d.d, d.e, d.x}
+}
+
+func newTestDAG() *testDAG {
+	d := &testDAG{}
+	d.g = genHeader()
+	d.a = genHeader(d.g)
+	d.b = genHeader(d.g)
+	d.c = genHeader(d.a, d.b)
+	d.d = genHeader(d.a)
+	d.e = genHeader(d.c, d.d)
+	d.x = genHeader()
+	return d
+}
+
+func hashes(bhxs ...*m.BlockHeaderExt) []m.BlockHash {
+	hs := make([]m.BlockHash, len(bhxs))
+	for i, bhx := range bhxs {
+		hs[i] = bhx.Hash
+	}
+	return hs
+}
+
+func TestTraversal(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	d := newTestDAG()
+	l := NewMemLookup(d.all()...)
+
+	parents, err := Parents(l, d.c.Hash)
+	req.NoError(err)
+	assr.Equal([]*m.BlockHeaderExt{d.a, d.b}, parents)
+	parents, err = Parents(l, d.g.Hash)
+	req.NoError(err)
+	assr.Empty(parents)
+
+	children, err := Children(l, d.a.Hash)
+	req.NoError(err)
+	assr.ElementsMatch([]*m.BlockHeaderExt{d.c, d.d}, children)
+	assr.Equal(Tips(children), hashes(children...))
+
+	_, err = Parents(l, test.TestHash)
+	assr.ErrorIs(err, ErrNotFound)
+
+	assr.ElementsMatch(hashes(d.e, d.x), l.Tips())
+	assr.Equal(l.Tips(), Tips(d.all()))
+	assr.ElementsMatch(hashes(d.c, d.d), Tips([]*m.BlockHeaderExt{d.g, d.a, d.b, d.c, d.d}))
+}
+
+func TestIsAncestor(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	d := newTestDAG()
+	l := NewMemLookup(d.all()...)
+
+	for _, c := range []struct {
+		ancestor, hash *m.BlockHeaderExt
+		expected       bool
+	}{
+		{d.g, d.e, true},
+		{d.b, d.e, true},
+		{d.a, d.c, true},
+		{d.b, d.d, false},
+		{d.d, d.c, false},
+		{d.e, d.g, false},
+		{d.e, d.e, false},
+		{d.x, d.e, false},
+	} {
+		ok, err := IsAncestor(l, c.ancestor.Hash, c.hash.Hash)
+		req.NoError(err)
+		assr.Equal(c.expected, ok)
+	}
+}
+
+func TestLowestCommonAncestors(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	d := newTestDAG()
+	l := NewMemLookup(d.all()...)
+
+	for _, c := range []struct {
+		a, b     *m.BlockHeaderExt
+		expected []m.BlockHash
+	}{
+		{d.c, d.d, hashes(d.a)},
+		{d.a, d.b, hashes(d.g)},
+		{d.b, d.d, hashes(d.g)},
+		{d.c, d.e, hashes(d.c)},
+		{d.e, d.e, hashes(d.e)},
+		{d.x, d.e, nil},
+	} {
+		lcas, err := LowestCommonAncestors(l, c.a.Hash, c.b.Hash)
+		req.NoError(err)
+		assr.Equal(c.expected, lcas)
+		lcas, err = LowestCommonAncestors(l, c.b.Hash, c.a.Hash)
+		req.NoError(err)
+		assr.Equal(c.expected, lcas)
+	}
+
+	// Two lowest common ancestors
+	f := genHeader(d.a, d.b)
+	l.Add(f)
+	lcas, err := LowestCommonAncestors(l, d.c.Hash, f.Hash)
+	req.NoError(err)
+	assr.ElementsMatch(hashes(d.a, d.b), lcas)
+}
+
+func TestTopoSort(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	d := newTestDAG()
+	all := d.all()
+	sorted, err := TopoSort(all)
+	req.NoError(err)
+	req.Len(sorted, len(all))
+	pos := make(map[string]int, len(sorted))
+	for i, bhx := range sorted {
+		pos[string(bhx.Hash)] = i
+	}
+	for _, bhx := range all {
+		for _, ph := range bhx.PrevHashes {
+			assr.Less(pos[string(ph)], pos[string(bhx.Hash)])
+		}
+	}
+
+	// Deterministic regardless of the input order
+	for i := 0; i < 10; i++ {
+		shuffled := append([]*m.BlockHeaderExt{}, all...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		sorted_, err := TopoSort(append(shuffled, d.c))
+		req.NoError(err)
+		assr.Equal(sorted, sorted_)
+	}
+
+	// Missing parents are ignored
+	sorted, err = TopoSort([]*m.BlockHeaderExt{d.e, d.c})
+	req.NoError(err)
+	assr.Equal([]*m.BlockHeaderExt{d.c, d.e}, sorted)
+
+	// Cycle
+	bh1 := test.GenTestBlockHeaderWithExtra(nil)
+	bh2 := test.GenTestBlockHeaderWithExtra(nil)
+	bh1.PrevHashes = []m.BlockHash{test.TestHash2}
+	bh2.PrevHashes = []m.BlockHash{test.TestHash}
+	bhx1 := &m.BlockHeaderExt{BlockHeader: bh1, Hash: test.TestHash}
+	bhx2 := &m.BlockHeaderExt{BlockHeader: bh2, Hash: test.TestHash2}
+	_, err = TopoSort([]*m.BlockHeaderExt{d.g, bhx1, bhx2})
+	assr.ErrorIs(err, ErrCycle)
+}
+
+func TestCheckHeight(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	d := newTestDAG()
+	l := NewMemLookup(d.all()...)
+	for _, bhx := range d.all() {
+		req.NoError(CheckHeight(l, bhx))
+	}
+
+	bh := *d.e.BlockHeader
+	bh.Height = 2
+	bhx, err := test.Util.ExtendBlockHeader(&bh)
+	req.NoError(err)
+	assr.ErrorIs(CheckHeight(l, bhx), ErrHeightMismatch)
+
+	bh = *d.g.BlockHeader
+	bh.Height = 1
+	bhx, err = test.Util.ExtendBlockHeader(&bh)
+	req.NoError(err)
+	assr.ErrorIs(CheckHeight(l, bhx), ErrHeightMismatch)
+
+	bh.PrevHashes = []m.BlockHash{test.TestHash}
+	bhx, err = test.Util.ExtendBlockHeader(&bh)
+	req.NoError(err)
+	assr.ErrorIs(CheckHeight(l, bhx), ErrNotFound)
+}
@@ -0,0 +1,170 @@
+package dag
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	m "github.com/daotl/go-doubl/model"
+)
+
+// Less reports whether `a` comes before `b` in the linear order of blocks, which is by Height and
+// then by the hash bytes.
+func Less(a, b *m.BlockHeaderExt) bool {
+	if a.Height != b.Height {
+		return a.Height < b.Height
+	}
+	return bytes.Compare(a.Hash, b.Hash) < 0
+}
+
+// Linearizer incrementally arranges the blocks of a DAG ledger in a deterministic linear order,
+// which can be used as the execution order of the Transactions in them. It's safe for concurrent
+// use.
+//
+// The order of a tip is all the blocks reachable from it sorted by Less, so it only depends on the
+// tip. The heights of the blocks are checked with CheckHeight, so every block comes after its
+// parents.
+//
+// A Linearizer keeps the order of its last tip, and Extend moves it to a new tip. Blocks merged
+// from concurrent branches may come before some ordered blocks, so Extend reports how many ordered
+// blocks keep their positions, and the blocks after them should be re-executed.
+type Linearizer struct {
+	l     Lookup
+	mtx   sync.RWMutex
+	tip   m.BlockHash
+	order []*m.BlockHeaderExt
+	pos   map[string]int
+}
+
+// NewLinearizer creates a new Linearizer looking up block headers from `l`.
+func NewLinearizer(l Lookup) *Linearizer {
+	return &Linearizer{l: l, pos: make(map[string]int)}
+}
+
+// Linearize returns all the blocks reachable from `tip` in linear order, see Linearizer.
+func Linearize(l Lookup, tip m.BlockHash) ([]*m.BlockHeaderExt, error) {
+	_, order, err := NewLinearizer(l).Extend(tip)
+	return order, err
+}
+
+// Extend moves the order to the order of `tip`, so it's the same as Linearize(l, tip). It returns
+// the number of the blocks at the start of the previous order that keep their positions, and the
+// blocks after them in the new order.
+//
+// If `tip` descends from the last tip, only the blocks not ordered yet are looked up and merged
+// into the order. Otherwise the order of `tip` is recomputed, and the blocks of the previous order
+// after the common prefix are dropped. It's a no-op if `tip` is already ordered.
+//
+// The order is not changed if an error is returned.
+func (z *Linearizer) Extend(tip m.BlockHash) (keep int, blocks []*m.BlockHeaderExt, err error) {
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	if _, ok := z.pos[string(tip)]; ok {
+		return len(z.order), nil, nil
+	}
+
+	// All ancestors of an ordered block are ordered, so there is no need to walk past it. The last
+	// tip is reached if `tip` descends from it, since no ordered block descends from the last tip.
+	descends := false
+	var added []*m.BlockHeaderExt
+	err = walkAncestors(z.l, tip, func(bhx *m.BlockHeaderExt) (bool, error) {
+		if _, ok := z.pos[string(bhx.Hash)]; ok {
+			descends = descends || bytes.Equal(bhx.Hash, z.tip)
+			return false, nil
+		}
+		if err := CheckHeight(z.l, bhx); err != nil {
+			return false, err
+		}
+		added = append(added, bhx)
+		return true, nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	sort.Slice(added, func(i, j int) bool { return Less(added[i], added[j]) })
+
+	if descends || z.tip == nil {
+		// Merge the added blocks into the ordered blocks after the first added block
+		keep = sort.Search(len(z.order), func(i int) bool { return Less(added[0], z.order[i]) })
+		blocks = make([]*m.BlockHeaderExt, 0, len(z.order)-keep+len(added))
+		rest := z.order[keep:]
+		for len(rest) > 0 || len(added) > 0 {
+			if len(added) == 0 || len(rest) > 0 && Less(rest[0], added[0]) {
+				blocks, rest = append(blocks, rest[0]), rest[1:]
+			} else {
+				blocks, added = append(blocks, added[0]), added[1:]
+			}
+		}
+	} else {
+		// The ordered blocks that are not ancestors of `tip` have to be dropped
+		order, err := z.ancestors(tip)
+		if err != nil {
+			return 0, nil, err
+		}
+		for keep < len(z.order) && keep < len(order) &&
+			bytes.Equal(z.order[keep].Hash, order[keep].Hash) {
+			keep++
+		}
+		blocks = order[keep:]
+	}
+
+	for _, bhx := range z.order[keep:] {
+		delete(z.pos, string(bhx.Hash))
+	}
+	z.order = append(z.order[:keep:keep], blocks...)
+	for i := keep; i < len(z.order); i++ {
+		z.pos[string(z.order[i].Hash)] = i
+	}
+	z.tip = tip
+	return keep, blocks, nil
+}
+
+// ancestors returns `tip` and all its ancestors sorted by Less. The heights of the blocks not
+// ordered yet are checked with CheckHeight.
+func (z *Linearizer) ancestors(tip m.BlockHash) ([]*m.BlockHeaderExt, error) {
+	var order []*m.BlockHeaderExt
+	err := walkAncestors(z.l, tip, func(bhx *m.BlockHeaderExt) (bool, error) {
+		if _, ok := z.pos[string(bhx.Hash)]; !ok {
+			if err := CheckHeight(z.l, bhx); err != nil {
+				return false, err
+			}
+		}
+		order = append(order, bhx)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(order, func(i, j int) bool { return Less(order[i], order[j]) })
+	return order, nil
+}
+
+// Tip returns the hash of the last tip, or nil if nothing is ordered yet.
+func (z *Linearizer) Tip() m.BlockHash {
+	z.mtx.RLock()
+	defer z.mtx.RUnlock()
+	return z.tip
+}
+
+// Order returns all the ordered blocks.
+func (z *Linearizer) Order() []*m.BlockHeaderExt {
+	z.mtx.RLock()
+	defer z.mtx.RUnlock()
+	return append([]*m.BlockHeaderExt(nil), z.order...)
+}
+
+// Position returns the position of the block with hash `hash` in the order, or false if it's not
+// ordered.
+func (z *Linearizer) Position(hash m.BlockHash) (int, bool) {
+	z.mtx.RLock()
+	defer z.mtx.RUnlock()
+	i, ok := z.pos[string(hash)]
+	return i, ok
+}
+
+// Len returns the number of the ordered blocks.
+func (z *Linearizer) Len() int {
+	z.mtx.RLock()
+	defer z.mtx.RUnlock()
+	return len(z.order)
+}
@@ -0,0 +1,135 @@
+package dag_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/dag"
+	m "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+// genFixedHeader creates a BlockHeaderExt for test like genHeader, with the hash of all bytes `id`,
+// so that the order of blocks at the same height is fixed.
+func genFixedHeader(id byte, parents ...*m.BlockHeaderExt) *m.BlockHeaderExt {
+	bhx := genHeader(parents...)
+	bhx.Hash = bytes.Repeat([]byte{id}, m.HashSize)
+	return bhx
+}
+
+func TestLinearize(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	// a -> g, b -> g, c -> (a, b), d -> a, e -> (c, d), with d before c at height 2
+	g := genFixedHeader(0x10)
+	a := genFixedHeader(0x30, g)
+	b := genFixedHeader(0x20, g)
+	c := genFixedHeader(0x40, a, b)
+	d := genFixedHeader(0x05, a)
+	e := genFixedHeader(0x60, c, d)
+	l := NewMemLookup(g, a, b, c, d, e)
+
+	order, err := Linearize(l, e.Hash)
+	req.NoError(err)
+	assr.Equal([]*m.BlockHeaderExt{g, b, a, d, c, e}, order)
+	order, err = Linearize(l, d.Hash)
+	req.NoError(err)
+	assr.Equal([]*m.BlockHeaderExt{g, a, d}, order)
+
+	t.Run("Incremental", func(t *testing.T) {
+		z := NewLinearizer(l)
+		keep, blocks, err := z.Extend(c.Hash)
+		req.NoError(err)
+		assr.Equal(0, keep)
+		assr.Equal([]*m.BlockHeaderExt{g, b, a, c}, blocks)
+		assr.Equal(c.Hash, z.Tip())
+
+		// Appended after the last tip
+		f := genFixedHeader(0x50, c)
+		l.Add(f)
+		keep, blocks, err = z.Extend(f.Hash)
+		req.NoError(err)
+		assr.Equal(4, keep)
+		assr.Equal([]*m.BlockHeaderExt{f}, blocks)
+
+		// A merged block at a lower height is ordered before the blocks after it
+		h := genFixedHeader(0x01, e, f)
+		l.Add(h)
+		keep, blocks, err = z.Extend(h.Hash)
+		req.NoError(err)
+		assr.Equal(3, keep)
+		assr.Equal([]*m.BlockHeaderExt{d, c, f, e, h}, blocks)
+		order, err := Linearize(l, h.Hash)
+		req.NoError(err)
+		assr.Equal(order, z.Order())
+		assr.Equal(8, z.Len())
+		i, ok := z.Position(d.Hash)
+		assr.True(ok)
+		assr.Equal(3, i)
+
+		keep, blocks, err = z.Extend(a.Hash)
+		req.NoError(err)
+		assr.Equal(8, keep)
+		assr.Empty(blocks)
+		assr.Equal(h.Hash, z.Tip())
+	})
+
+	t.Run("Competing tip", func(t *testing.T) {
+		// The order after the common prefix is replaced by the order of a tip on another branch
+		z := NewLinearizer(l)
+		_, _, err := z.Extend(e.Hash)
+		req.NoError(err)
+		x := genFixedHeader(0x70, b)
+		l.Add(x)
+		keep, blocks, err := z.Extend(x.Hash)
+		req.NoError(err)
+		assr.Equal(2, keep)
+		assr.Equal([]*m.BlockHeaderExt{x}, blocks)
+		assr.Equal([]*m.BlockHeaderExt{g, b, x}, z.Order())
+		_, ok := z.Position(e.Hash)
+		assr.False(ok)
+
+		// Then merged back incrementally
+		y := genFixedHeader(0x02, e, x)
+		l.Add(y)
+		keep, blocks, err = z.Extend(y.Hash)
+		req.NoError(err)
+		assr.Equal(2, keep)
+		assr.Equal([]*m.BlockHeaderExt{a, d, c, x, e, y}, blocks)
+		order, err := Linearize(l, y.Hash)
+		req.NoError(err)
+		assr.Equal(order, z.Order())
+
+		// An unrelated root shares no prefix
+		r := genFixedHeader(0x00)
+		l.Add(r)
+		keep, blocks, err = z.Extend(r.Hash)
+		req.NoError(err)
+		assr.Equal(0, keep)
+		assr.Equal([]*m.BlockHeaderExt{r}, blocks)
+		assr.Equal(1, z.Len())
+	})
+
+	t.Run("Height mismatch", func(t *testing.T) {
+		bh := *b.BlockHeader
+		bh.Height = 2
+		bhx, err := test.Util.ExtendBlockHeader(&bh)
+		req.NoError(err)
+		l.Add(bhx)
+		child := genHeader(bhx, a)
+		l.Add(child)
+		z := NewLinearizer(l)
+		_, _, err = z.Extend(child.Hash)
+		assr.ErrorIs(err, ErrHeightMismatch)
+		assr.Empty(z.Order())
+		assr.Nil(z.Tip())
+
+		_, _, err = z.Extend(test.TestHash)
+		assr.ErrorIs(err, ErrNotFound)
+		assr.Equal(0, z.Len())
+	})
+}
@@ -0,0 +1,106 @@
+package dag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	m "github.com/daotl/go-doubl/model"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Lookup looks up the block headers of a DAG ledger and the relations between them.
+type Lookup interface {
+	// Header returns the BlockHeaderExt with hash `hash`, or ErrNotFound if it doesn't exist.
+	Header(hash m.BlockHash) (*m.BlockHeaderExt, error)
+
+	// Children returns the hashes of the known blocks which have `hash` in their PrevHashes,
+	// sorted in ascending order of the hash bytes.
+	Children(hash m.BlockHash) ([]m.BlockHash, error)
+}
+
+// MemLookup is an in-memory Lookup, it's safe for concurrent use.
+type MemLookup struct {
+	mtx      sync.RWMutex
+	headers  map[string]*m.BlockHeaderExt
+	children map[string][]m.BlockHash
+}
+
+var _ Lookup = (*MemLookup)(nil)
+
+// NewMemLookup creates a new MemLookup with the given block headers.
+func NewMemLookup(bhxs ...*m.BlockHeaderExt) *MemLookup {
+	l := &MemLookup{
+		headers:  make(map[string]*m.BlockHeaderExt, len(bhxs)),
+		children: make(map[string][]m.BlockHash, len(bhxs)),
+	}
+	for _, bhx := range bhxs {
+		l.Add(bhx)
+	}
+	return l
+}
+
+// Add adds a block header, the parents don't need to be added first.
+func (l *MemLookup) Add(bhx *m.BlockHeaderExt) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if _, ok := l.headers[string(bhx.Hash)]; ok {
+		return
+	}
+	l.headers[string(bhx.Hash)] = bhx
+	for _, ph := range bhx.PrevHashes {
+		l.children[string(ph)] = insertHash(l.children[string(ph)], bhx.Hash)
+	}
+}
+
+// Header implements Lookup.
+func (l *MemLookup) Header(hash m.BlockHash) (*m.BlockHeaderExt, error) {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	bhx, ok := l.headers[string(hash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: block header %x", ErrNotFound, hash)
+	}
+	return bhx, nil
+}
+
+// Children implements Lookup.
+func (l *MemLookup) Children(hash m.BlockHash) ([]m.BlockHash, error) {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	return append([]m.BlockHash(nil), l.children[string(hash)]...), nil
+}
+
+// Tips returns the hashes of the added blocks which are not parents of any added block,
+// sorted in ascending order of the hash bytes.
+func (l *MemLookup) Tips() []m.BlockHash {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	var tips []m.BlockHash
+	for k, bhx := range l.headers {
+		if len(l.children[k]) == 0 {
+			tips = append(tips, bhx.Hash)
+		}
+	}
+	sortHashes(tips)
+	return tips
+}
+
+// insertHash inserts `h` into sorted `hs` if it's not there yet.
+func insertHash(hs []m.BlockHash, h m.BlockHash) []m.BlockHash {
+	i := sort.Search(len(hs), func(i int) bool { return bytes.Compare(hs[i], h) >= 0 })
+	if i < len(hs) && bytes.Equal(hs[i], h) {
+		return hs
+	}
+	hs = append(hs, nil)
+	copy(hs[i+1:], hs[i:])
+	hs[i] = h
+	return hs
+}
+
+func sortHashes(hs []m.BlockHash) {
+	sort.Slice(hs, func(i, j int) bool { return bytes.Compare(hs[i], hs[j]) < 0 })
+}
@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/crpt/go-crpt v0.5.1
+	github.com/crpt/go-merkle v0.0.0-20211202024952-07ef5d0dcfc0
 	github.com/daotl/cbor-gen v0.0.7
 	github.com/daotl/go-marsha v0.3.0
 	github.com/ipfs/go-cid v0.1.0
@@ -15,7 +16,6 @@ require (
 
 require (
 	github.com/btcsuite/btcd v0.22.0-beta // indirect
-	github.com/crpt/go-merkle v0.0.0-20211202024952-07ef5d0dcfc0 // indirect
 	github.com/daotl/go-acei v0.0.0-20211201154418-8daef5059165 // indirect
 	github.com/daotl/guts v0.0.0-20211209102048-f83c8ade78e8 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -39,7 +39,6 @@ require (
 	github.com/polydawn/refmt v0.0.0-20201211092308-30ac6d18308e // indirect
 	github.com/sasha-s/go-deadlock v0.2.1-0.20190427202633-1595213edefa // indirect
 	github.com/spaolacci/murmur3 v1.1.0 // indirect
-	github.com/stretchr/objx v0.1.1 // indirect
 	github.com/tendermint/tendermint v0.35.0 // indirect
 	github.com/whyrusleeping/cbor-gen v0.0.0-20200123233031-1cdf64d27158 // indirect
 	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519 // indirect
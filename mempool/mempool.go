@@ -0,0 +1,369 @@
+// Package mempool provides a pool of the Transactions waiting to be included in blocks.
+package mempool
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	m "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/nonce"
+)
+
+var (
+	ErrDuplicate     = errors.New("transaction already in mempool")
+	ErrNonceConflict = errors.New("another transaction with the same sender and nonce in mempool")
+	ErrMempoolFull   = errors.New("mempool is full")
+	ErrInvalidTx     = errors.New("invalid transaction")
+)
+
+// entry is a pooled Transaction.
+type entry struct {
+	txx  *m.TransactionExt
+	seq  uint64
+	size uint64
+}
+
+// sender holds the pooled Transactions from an address sorted by nonce.
+type sender struct {
+	addr    string
+	entries []*entry
+	size    uint64
+}
+
+// Mempool is a pool of the Transactions waiting to be included in blocks, it's safe for concurrent
+// use.
+//
+// Transactions are admitted after their signatures are verified, and deduplicated by hash. The
+// Transactions from the same sender are ordered by nonce. The total memory occupied by the pooled
+// Transactions, estimated by TransactionExt.Size, can be capped by MaxBytes.
+type Mempool struct {
+	// Maximum total estimated size of the pooled Transactions in bytes, 0 means unlimited.
+	//
+	// When a new Transaction doesn't fit, the Transactions with the highest nonces of the senders
+	// occupying the most memory are evicted first. The new Transaction is rejected with
+	// ErrMempoolFull instead if it would be evicted itself.
+	MaxBytes uint64
+
+	util   *m.Util
+	nonces *nonce.Tracker
+
+	mtx     sync.RWMutex
+	txs     map[string]*entry
+	senders map[string]*sender
+	size    uint64
+	seq     uint64
+}
+
+// New creates a new Mempool verifying Transactions with `u`.
+//
+// If `nonces` is not nil, Transactions with used nonces are rejected, and ReapForBlock only reaps
+// the Transactions that can be applied to `nonces` in order. `nonces` should be kept updated with
+// the applied blocks, after which Update should be called.
+func New(u *m.Util, nonces *nonce.Tracker) *Mempool {
+	return &Mempool{
+		util:    u,
+		nonces:  nonces,
+		txs:     make(map[string]*entry),
+		senders: make(map[string]*sender),
+	}
+}
+
+// Add verifies `txx` and adds it to the Mempool, possibly evicting other Transactions, see
+// MaxBytes. The Transaction and hash of `txx` must match TransactionExt.Bytes, see
+// Util.CheckTransactionExt, and the signature must be valid. Genesis transactions are always rejected, since they can only be
+// included in the genesis block.
+func (p *Mempool) Add(txx *m.TransactionExt) error {
+	if txx == nil || txx.Transaction == nil || len(txx.Hash) == 0 {
+		return fmt.Errorf("%w: no transaction hash", ErrInvalidTx)
+	}
+	if txx.Type == m.TransactionTypeGenesis {
+		return fmt.Errorf("%w: genesis transaction %x", ErrInvalidTx, txx.Hash)
+	}
+	// The Transaction is used for ordering and the hash for deduplication, while the signature is
+	// verified against the bytes.
+	if err := p.util.CheckTransactionExt(txx); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTx, err)
+	}
+	ok, err := p.util.VerifyTransactionExtSignature(txx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTx, err)
+	} else if !ok {
+		return fmt.Errorf("%w: transaction %x", m.ErrInvalidSignature, txx.Hash)
+	}
+	if p.nonces != nil {
+		if err = p.nonces.Check(txx.From, txx.Nonce); errors.Is(err, nonce.ErrNonceUsed) {
+			return err
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if _, ok := p.txs[string(txx.Hash)]; ok {
+		return fmt.Errorf("%w: %x", ErrDuplicate, txx.Hash)
+	}
+	s := p.senders[string(txx.From)]
+	if s != nil {
+		if i := s.search(txx.Nonce); i < len(s.entries) && s.entries[i].txx.Nonce == txx.Nonce {
+			return fmt.Errorf("%w: nonce %d of %x", ErrNonceConflict, txx.Nonce, txx.From)
+		}
+	}
+
+	e := &entry{txx: txx, seq: p.seq, size: txx.Size()}
+	if err = p.makeRoom(e); err != nil {
+		return err
+	}
+	p.seq++
+	if s = p.senders[string(txx.From)]; s == nil {
+		s = &sender{addr: string(txx.From)}
+		p.senders[s.addr] = s
+	}
+	s.insert(e)
+	p.txs[string(txx.Hash)] = e
+	p.size += e.size
+	return nil
+}
+
+// makeRoom evicts Transactions until `e` fits in MaxBytes. Nothing is evicted if it fails.
+func (p *Mempool) makeRoom(e *entry) error {
+	if p.MaxBytes == 0 {
+		return nil
+	}
+	if e.size > p.MaxBytes {
+		return fmt.Errorf("%w: transaction size %d exceeds %d", ErrMempoolFull, e.size, p.MaxBytes)
+	}
+
+	// Remaining sizes and numbers of entries of the senders after evicting the victims
+	sizes := make(map[*sender]uint64)
+	counts := make(map[*sender]int)
+	var victims []*entry
+	for size := p.size + e.size; size > p.MaxBytes; {
+		var victim *sender
+		for _, s := range p.senders {
+			if _, ok := sizes[s]; !ok {
+				sizes[s], counts[s] = s.size, len(s.entries)
+			}
+			if counts[s] == 0 {
+				continue
+			}
+			if victim == nil || sizes[s] > sizes[victim] ||
+				(sizes[s] == sizes[victim] && s.addr < victim.addr) {
+				victim = s
+			}
+		}
+		tail := victim.entries[counts[victim]-1]
+		if victim.addr == string(e.txx.From) && e.txx.Nonce > tail.txx.Nonce {
+			return fmt.Errorf("%w: no room for transaction %x", ErrMempoolFull, e.txx.Hash)
+		}
+		victims = append(victims, tail)
+		sizes[victim] -= tail.size
+		counts[victim]--
+		size -= tail.size
+	}
+	for _, v := range victims {
+		p.remove(v)
+	}
+	return nil
+}
+
+// remove removes `e` from the Mempool.
+func (p *Mempool) remove(e *entry) {
+	delete(p.txs, string(e.txx.Hash))
+	p.size -= e.size
+	s := p.senders[string(e.txx.From)]
+	s.delete(e)
+	if len(s.entries) == 0 {
+		delete(p.senders, s.addr)
+	}
+}
+
+// Has reports whether the Transaction with hash `hash` is in the Mempool.
+func (p *Mempool) Has(hash m.TransactionHash) bool {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	_, ok := p.txs[string(hash)]
+	return ok
+}
+
+// Get returns the Transaction with hash `hash`, or nil if it's not in the Mempool.
+func (p *Mempool) Get(hash m.TransactionHash) *m.TransactionExt {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	if e, ok := p.txs[string(hash)]; ok {
+		return e.txx
+	}
+	return nil
+}
+
+// Remove removes the Transaction with hash `hash`, it reports whether the Transaction was in the
+// Mempool.
+func (p *Mempool) Remove(hash m.TransactionHash) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	e, ok := p.txs[string(hash)]
+	if ok {
+		p.remove(e)
+	}
+	return ok
+}
+
+// Update removes `txxs`, which are included in an applied block, from the Mempool. If the Mempool
+// has a nonce.Tracker, the Transactions of the same senders with nonces used now are also removed.
+func (p *Mempool) Update(txxs m.TransactionExtSlice) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for _, txx := range txxs {
+		if e, ok := p.txs[string(txx.Hash)]; ok {
+			p.remove(e)
+		}
+	}
+	if p.nonces == nil {
+		return
+	}
+	for _, txx := range txxs {
+		s := p.senders[string(txx.From)]
+		if s == nil {
+			continue
+		}
+		for _, e := range append([]*entry(nil), s.entries...) {
+			if errors.Is(p.nonces.Check(e.txx.From, e.txx.Nonce), nonce.ErrNonceUsed) {
+				p.remove(e)
+			}
+		}
+	}
+}
+
+// Len returns the number of the pooled Transactions.
+func (p *Mempool) Len() int {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return len(p.txs)
+}
+
+// Size returns the total estimated size of the pooled Transactions in bytes.
+func (p *Mempool) Size() uint64 {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return p.size
+}
+
+// ReapForBlock returns the pooled Transactions to be included in a new block, the total length of
+// whose encoded bytes doesn't exceed `maxBytes` and the number of which doesn't exceed `maxCount`.
+// 0 `maxBytes` and non-positive `maxCount` mean no limit. The Transactions are not removed from the
+// Mempool, see Update.
+//
+// The Transactions from the same sender are reaped in the order of nonce, and stop at the first one
+// that doesn't fit. Different senders take turns in the order their next Transactions arrived. If
+// the Mempool has a nonce.Tracker, only the Transactions that can be applied in order are reaped,
+// e.g., with the nonce.Strict policy, the nonces of every sender must be sequential starting from
+// the next expected one.
+//
+// The returned TransactionExtSlice can be passed to Util.GenRootHashFromTransactionExtSlice directly.
+func (p *Mempool) ReapForBlock(maxBytes uint64, maxCount int) m.TransactionExtSlice {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	h := make(cursorHeap, 0, len(p.senders))
+	for _, s := range p.senders {
+		c := &cursor{s: s}
+		if p.nonces != nil && p.nonces.Policy() == nonce.Strict {
+			c.next = p.nonces.Next(m.Address(s.addr))
+		}
+		if c.skip(p.nonces) {
+			h = append(h, c)
+		}
+	}
+	heap.Init(&h)
+
+	var txxs m.TransactionExtSlice
+	var bytes_ uint64
+	for h.Len() > 0 && (maxCount <= 0 || len(txxs) < maxCount) {
+		c := h[0]
+		e := c.s.entries[c.i]
+		if maxBytes > 0 && bytes_+uint64(len(e.txx.Bytes)) > maxBytes {
+			heap.Pop(&h)
+			continue
+		}
+		txxs = append(txxs, e.txx)
+		bytes_ += uint64(len(e.txx.Bytes))
+		c.i++
+		c.next = e.txx.Nonce + 1
+		if c.skip(p.nonces) {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return txxs
+}
+
+// cursor iterates the Transactions of a sender in ReapForBlock.
+type cursor struct {
+	s *sender
+	i int
+	// Next nonce expected by the nonce.Strict policy
+	next uint64
+}
+
+// skip skips the Transactions with used nonces, and reports whether the current Transaction can be
+// reaped.
+func (c *cursor) skip(nonces *nonce.Tracker) bool {
+	if nonces == nil {
+		return c.i < len(c.s.entries)
+	}
+	for ; c.i < len(c.s.entries); c.i++ {
+		txx := c.s.entries[c.i].txx
+		switch nonces.Policy() {
+		case nonce.Strict:
+			if txx.Nonce < c.next {
+				continue
+			}
+			return txx.Nonce == c.next
+		default:
+			if nonces.Check(txx.From, txx.Nonce) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cursorHeap is a min-heap of cursors ordered by the arrival of their current Transactions.
+type cursorHeap []*cursor
+
+func (h cursorHeap) Len() int { return len(h) }
+func (h cursorHeap) Less(i, j int) bool {
+	return h[i].s.entries[h[i].i].seq < h[j].s.entries[h[j].i].seq
+}
+func (h cursorHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *cursorHeap) Push(x interface{}) { *h = append(*h, x.(*cursor)) }
+func (h *cursorHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// search returns the index of the first entry with nonce not less than `nonce`.
+func (s *sender) search(nonce uint64) int {
+	return sort.Search(len(s.entries), func(i int) bool { return s.entries[i].txx.Nonce >= nonce })
+}
+
+func (s *sender) insert(e *entry) {
+	i := s.search(e.txx.Nonce)
+	s.entries = append(s.entries, nil)
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = e
+	s.size += e.size
+}
+
+func (s *sender) delete(e *entry) {
+	i := s.search(e.txx.Nonce)
+	if i < len(s.entries) && bytes.Equal(s.entries[i].txx.Hash, e.txx.Hash) {
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		s.size -= e.size
+	}
+}
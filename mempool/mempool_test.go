@@ -0,0 +1,198 @@
+package mempool_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/crpt/go-crpt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/mempool"
+	m "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/nonce"
+	"github.com/daotl/go-doubl/test"
+)
+
+var txCounter int
+
+// genTx creates a Transaction from the address of `priv` with `nonce` signed by `priv`, all the
+// Transactions with nonces less than 24 have the same size.
+func genTx(priv crpt.PrivateKey, nonce uint64) *m.TransactionExt {
+	txCounter++
+	tx := &m.Transaction{
+		Type:  4,
+		From:  priv.Public().Address(),
+		Nonce: nonce,
+		To:    test.TestAddress2,
+		Data:  []byte(fmt.Sprintf("%08d", txCounter)),
+	}
+	if err := test.Util.SignTransaction(tx, priv); err != nil {
+		panic(err)
+	}
+	txx, err := test.Util.ExtendTransaction(tx)
+	if err != nil {
+		panic(err)
+	}
+	return txx
+}
+
+func genKey() crpt.PrivateKey {
+	_, priv, err := test.Crpt.GenerateKey(nil)
+	if err != nil {
+		panic(err)
+	}
+	return priv
+}
+
+func hashes(txxs m.TransactionExtSlice) []m.TransactionHash {
+	hs := make([]m.TransactionHash, len(txxs))
+	for i, txx := range txxs {
+		hs[i] = txx.Hash
+	}
+	return hs
+}
+
+func TestAdd(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	p := New(test.Util, nil)
+	a := genKey()
+	txx := genTx(a, 0)
+	req.NoError(p.Add(txx))
+	assr.True(p.Has(txx.Hash))
+	assr.Equal(txx, p.Get(txx.Hash))
+	assr.Equal(1, p.Len())
+	assr.Equal(txx.Size(), p.Size())
+
+	assr.ErrorIs(p.Add(txx), ErrDuplicate)
+	assr.ErrorIs(p.Add(genTx(a, 0)), ErrNonceConflict)
+	assr.ErrorIs(p.Add(test.GenRandomTransactionExt()), m.ErrInvalidSignature)
+	assr.ErrorIs(p.Add(&m.TransactionExt{}), ErrInvalidTx)
+
+	// The hash and Transaction must match the signed bytes
+	forged := *txx
+	forged.Hash = test.GenRandomHash()
+	assr.ErrorIs(p.Add(&forged), ErrInvalidTx)
+	forged = *txx
+	forged.Transaction = &m.Transaction{}
+	*forged.Transaction = *txx.Transaction
+	forged.Nonce = 7
+	assr.ErrorIs(p.Add(&forged), ErrInvalidTx)
+	assr.Equal(1, p.Len())
+
+	// Genesis transactions are never admitted, even if the signature is valid
+	genesis, _, err := test.Util.NewLedger(a, &m.GenesisPayload{Creators: [][]byte{test.TestAddress}})
+	req.NoError(err)
+	assr.ErrorIs(p.Add(genesis), ErrInvalidTx)
+	assr.Equal(1, p.Len())
+
+	assr.True(p.Remove(txx.Hash))
+	assr.False(p.Remove(txx.Hash))
+	assr.Nil(p.Get(txx.Hash))
+	assr.Equal(0, p.Len())
+	assr.Equal(uint64(0), p.Size())
+}
+
+func TestReapForBlock(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	p := New(test.Util, nil)
+	a, b := genKey(), genKey()
+	a0, a1, a2 := genTx(a, 0), genTx(a, 1), genTx(a, 2)
+	b5, b6 := genTx(b, 5), genTx(b, 6)
+	for _, txx := range []*m.TransactionExt{b6, a2, a0, b5, a1} {
+		req.NoError(p.Add(txx))
+	}
+
+	// Senders take turns in the order their next Transactions arrived
+	txxs := p.ReapForBlock(0, 0)
+	assr.Equal(hashes(m.TransactionExtSlice{a0, b5, b6, a1, a2}), hashes(txxs))
+	assr.NotEmpty(test.Util.GenRootHashFromTransactionExtSlice(txxs))
+	assr.Equal(5, p.Len())
+
+	txxs = p.ReapForBlock(0, 3)
+	assr.Equal(hashes(m.TransactionExtSlice{a0, b5, b6}), hashes(txxs))
+	txxs = p.ReapForBlock(uint64(len(a0.Bytes)*2+1), 0)
+	assr.Equal(hashes(m.TransactionExtSlice{a0, b5}), hashes(txxs))
+
+	p.Update(m.TransactionExtSlice{a0, b5})
+	assr.Equal(3, p.Len())
+	txxs = p.ReapForBlock(0, 0)
+	assr.Equal(hashes(m.TransactionExtSlice{b6, a1, a2}), hashes(txxs))
+}
+
+func TestNonces(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	tr := nonce.New(nonce.Strict)
+	p := New(test.Util, tr)
+	a, b := genKey(), genKey()
+	a0, a1, a3 := genTx(a, 0), genTx(a, 1), genTx(a, 3)
+	b0 := genTx(b, 0)
+	for _, txx := range []*m.TransactionExt{a3, a1, a0, b0} {
+		req.NoError(p.Add(txx))
+	}
+
+	// Reaping stops at the gap
+	txxs := p.ReapForBlock(0, 0)
+	assr.Equal(hashes(m.TransactionExtSlice{a0, a1, b0}), hashes(txxs))
+
+	bh := test.GenTestBlockHeaderWithExtra(nil)
+	bx, err := test.Util.ExtendBlock(&m.Block{
+		Header: bh,
+		Txs:    m.TransactionSlice{*a0.Transaction, *a1.Transaction},
+	})
+	req.NoError(err)
+	req.NoError(tr.ApplyBlock(bx))
+	assr.ErrorIs(p.Add(genTx(a, 1)), nonce.ErrNonceUsed)
+
+	// A Transaction with a used nonce that is not in the block is also removed
+	p.Update(m.TransactionExtSlice{a0})
+	assr.Equal(2, p.Len())
+	assr.False(p.Has(a1.Hash))
+	txxs = p.ReapForBlock(0, 0)
+	assr.Equal(hashes(m.TransactionExtSlice{b0}), hashes(txxs))
+
+	a2 := genTx(a, 2)
+	req.NoError(p.Add(a2))
+	txxs = p.ReapForBlock(0, 0)
+	assr.Equal(hashes(m.TransactionExtSlice{b0, a2, a3}), hashes(txxs))
+}
+
+func TestEviction(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	p := New(test.Util, nil)
+	a, b := genKey(), genKey()
+	a0, a1, a2 := genTx(a, 0), genTx(a, 1), genTx(a, 2)
+	size := a0.Size()
+	p.MaxBytes = size * 3
+	for _, txx := range []*m.TransactionExt{a0, a1, a2} {
+		req.NoError(p.Add(txx))
+	}
+
+	// The Transaction with the highest nonce of the sender occupying the most memory is evicted
+	b0 := genTx(b, 0)
+	req.NoError(p.Add(b0))
+	assr.False(p.Has(a2.Hash))
+	assr.Equal(3, p.Len())
+	assr.Equal(size*3, p.Size())
+
+	// The new Transaction would be evicted itself
+	assr.ErrorIs(p.Add(genTx(a, 5)), ErrMempoolFull)
+	assr.Equal(3, p.Len())
+
+	b1 := genTx(b, 1)
+	req.NoError(p.Add(b1))
+	assr.False(p.Has(a1.Hash))
+	assr.Equal(hashes(m.TransactionExtSlice{a0, b0, b1}), hashes(p.ReapForBlock(0, 0)))
+
+	p.MaxBytes = size - 1
+	assr.ErrorIs(p.Add(genTx(b, 2)), ErrMempoolFull)
+	assr.Equal(3, p.Len())
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/crpt/go-crpt"
+	"github.com/crpt/go-crpt/batch"
+)
+
+// VerifyTransactionExtSlice verifies the signatures of all the transactions in the
+// TransactionExtSlice, and returns the indices of the transactions with invalid signatures in
+// ascending order, or nil if all are valid.
+//
+// It uses batch verification if Util.Crpt supports it (e.g., Ed25519), otherwise it verifies the
+// signatures one by one using Util.Workers goroutines.
+//
+// Genesis transactions always fail if Util.LedgerID is set, see VerifyTransactionExtSignature.
+func (u *Util) VerifyTransactionExtSlice(txxs TransactionExtSlice) (failed []int) {
+	if len(txxs) == 0 {
+		return nil
+	}
+	bv, ok := batch.NewBatchVerifier(u.Crpt.KeyType())
+	if !ok {
+		return u.verifyTransactionExtSliceParallel(txxs)
+	}
+
+	// Indices of the transactions added into the batch
+	added := make([]int, 0, len(txxs))
+	for i, txx := range txxs {
+		if u.addToBatch(bv, txx) {
+			added = append(added, i)
+		} else {
+			failed = append(failed, i)
+		}
+	}
+	if len(added) == 0 {
+		return failed
+	}
+
+	if ok, valid := bv.Verify(nil); !ok {
+		for j, v := range valid {
+			if !v {
+				failed = append(failed, added[j])
+			}
+		}
+		sort.Ints(failed)
+	}
+	return failed
+}
+
+// addToBatch adds the transaction signature into the BatchVerifier, it returns false if the
+// signature is malformed and can't be added.
+func (u *Util) addToBatch(bv crpt.BatchVerifier, txx *TransactionExt) bool {
+	if u.checkSignedType(txx.Type) != nil {
+		return false
+	}
+	msg, ok := u.extSignMessage(transactionSignDomain(txx.Type), txx.Bytes, txx.Sig)
+	if !ok {
+		return false
+	}
+	pub, err := u.Crpt.PublicKeyFromBytes(txx.From)
+	if err != nil {
+		return false
+	}
+	return bv.Add(pub, msg, txx.Sig) == nil
+}
+
+// verifyTransactionExtSliceParallel verifies the transaction signatures one by one in parallel.
+func (u *Util) verifyTransactionExtSliceParallel(txxs TransactionExtSlice) (failed []int) {
+	valid := make([]bool, len(txxs))
+	_ = u.parallelFor(len(txxs), func(i int) error {
+		valid[i], _ = u.VerifyTransactionExtSignature(txxs[i])
+		return nil
+	})
+	for i, v := range valid {
+		if !v {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/daotl/go-marsha"
+)
+
+var ErrNonCanonicalEncoding = errors.New("non-canonical encoding")
+
+// checkCanonical returns ErrNonCanonicalEncoding if Util.StrictDecoding is set and `bin` differs
+// from the encoding of `p` re-marshaled by Util.Mrsh, which is the canonical one.
+func (u *Util) checkCanonical(p marsha.StructPtr, bin []byte) error {
+	if !u.StrictDecoding {
+		return nil
+	}
+	canonical, err := u.Mrsh.MarshalStruct(p)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(canonical, bin) {
+		return fmt.Errorf("%w: %T of %d bytes, expected %d bytes", ErrNonCanonicalEncoding, p,
+			len(bin), len(canonical))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package model_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	ut := test.Util
+	strictUt := New(test.Mrsh, test.Crpt)
+	strictUt.StrictDecoding = true
+
+	// splice replaces bin[i:j] with `with`.
+	splice := func(bin []byte, i, j int, with ...byte) []byte {
+		return append(append(append([]byte{}, bin[:i]...), with...), bin[j:]...)
+	}
+
+	bx := test.GenSignedBlock([]BlockHash{test.TestHash}, 3, 0, 2)
+	var buf bytes.Buffer
+	_, err := bx.WriteTo(&buf)
+	req.NoError(err)
+	bin := buf.Bytes()
+
+	bx_, _, err := strictUt.ReadBlockExtFrom(bytes.NewReader(bin))
+	req.NoError(err)
+	assr.Equal(bx.Header.Hash, bx_.Header.Hash)
+	assr.Equal(bx.Txs, bx_.Txs)
+	txx, _, err := strictUt.ReadTransactionExtFrom(bytes.NewReader(bx.Txs[0].Bytes))
+	req.NoError(err)
+	assr.Equal(bx.Txs[0].Hash, txx.Hash)
+
+	// Block array of 2 encoded in 2 bytes (0x98 0x02) instead of 1 byte (0x82)
+	nonCanonical := splice(bin, 0, BlockCborInitialLength, 0x98, 2)
+	_, _, err = strictUt.ReadBlockExtFrom(bytes.NewReader(nonCanonical))
+	assr.ErrorIs(err, ErrNonCanonicalEncoding)
+	_, _, err = strictUt.ReadBlockHeaderExtFromBlockStream(bytes.NewReader(nonCanonical))
+	assr.ErrorIs(err, ErrNonCanonicalEncoding)
+
+	// Block initial byte is not checked in non-strict mode
+	nonCanonical = splice(bin, 0, BlockCborInitialLength, 0x83)
+	_, _, err = ut.ReadBlockExtFrom(bytes.NewReader(nonCanonical))
+	assr.NoError(err)
+	_, _, err = strictUt.ReadBlockExtFrom(bytes.NewReader(nonCanonical))
+	assr.ErrorIs(err, ErrNonCanonicalEncoding)
+	_, err = ut.ExtractBlockHeaderExtFromBlockBytes(nonCanonical)
+	assr.NoError(err)
+	_, err = strictUt.ExtractBlockHeaderExtFromBlockBytes(nonCanonical)
+	assr.ErrorIs(err, ErrNonCanonicalEncoding)
+
+	// Non-minimal CBOR headers are rejected by the decoder in both modes,
+	// e.g., Creator length 32 encoded in 3 bytes (0x59 0x0020) instead of 2 bytes (0x58 0x20)
+	i := BlockCborInitialLength + BlockHeaderCborInitialLength
+	req.Equal([]byte{0x58, AddressSize}, bin[i:i+2])
+	nonCanonical = splice(bin, i, i+2, 0x59, 0, AddressSize)
+	_, _, err = ut.ReadBlockExtFrom(bytes.NewReader(nonCanonical))
+	assr.Error(err)
+	_, _, err = strictUt.ReadBlockExtFrom(bytes.NewReader(nonCanonical))
+	assr.Error(err)
+}
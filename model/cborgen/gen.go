@@ -15,6 +15,11 @@ func main() {
 		model.Transaction{},
 		model.BlockHeader{},
 		model.Block{},
+		model.TransactionProof{},
+		model.TransactionMultiProof{},
+		model.GenesisPayload{},
+		model.LedgerParams{},
+		model.ParamChangePayload{},
 	); err != nil {
 		panic(err)
 	}
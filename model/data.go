@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/daotl/go-marsha"
+)
+
+var (
+	ErrUnknownDataType     = errors.New("no PayloadCtor registered for transaction type")
+	ErrDecodeData          = errors.New("failed to decode Data")
+	ErrPayloadTypeMismatch = errors.New("payload type mismatch")
+)
+
+// AsPayloadCtor converts a PayloadCtor of a concrete type into PayloadCtor[marsha.StructPtr] for
+// registering.
+func AsPayloadCtor[T marsha.StructPtr](ctor PayloadCtor[T]) PayloadCtor[marsha.StructPtr] {
+	return func() marsha.StructPtr { return ctor() }
+}
+
+// RegisterTransactionData registers the PayloadCtor used to decode Transaction.Data of the
+// transactions of type `t`.
+//
+// Registering should be done before using the Util concurrently.
+func (u *Util) RegisterTransactionData(t TransactionType, ctor PayloadCtor[marsha.StructPtr]) {
+	u.txPayloadCtors[t] = ctor
+}
+
+// DecodeTransactionData decodes Transaction.Data into a new payload created by the PayloadCtor
+// registered for the transaction type. It fails with ErrUnknownDataType if no PayloadCtor is
+// registered for the type, or ErrDecodeData if Data can't be decoded entirely.
+func (u *Util) DecodeTransactionData(tx *Transaction) (marsha.StructPtr, error) {
+	ctor := u.txPayloadCtors[tx.Type]
+	if ctor == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownDataType, tx.Type)
+	}
+	p := ctor()
+	if err := u.unmarshalWhole(tx.Data, p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecodeData, err)
+	}
+	return p, nil
+}
+
+// NewTransaction creates an unsigned Transaction of type `t` with Data set to the marshaled
+// `payload`. It fails with ErrUnknownDataType if no PayloadCtor is registered for `t`, or
+// ErrPayloadTypeMismatch if `payload` is not of the type the registered PayloadCtor creates.
+func (u *Util) NewTransaction(t TransactionType, from Address, nonce uint64, to Address,
+	payload marsha.StructPtr,
+) (*Transaction, error) {
+	ctor := u.txPayloadCtors[t]
+	if ctor == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownDataType, t)
+	}
+	if expected := reflect.TypeOf(ctor()); reflect.TypeOf(payload) != expected {
+		return nil, fmt.Errorf("%w: transaction type %d expects %v, got %T",
+			ErrPayloadTypeMismatch, t, expected, payload)
+	}
+	data, err := u.Mrsh.MarshalStruct(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{
+		Type:  t,
+		From:  from,
+		Nonce: nonce,
+		To:    to,
+		Data:  data,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestDataRegistry(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	ut := New(test.Mrsh, test.Crpt)
+	ut.RegisterTransactionData(5, AsPayloadCtor(func() *test.TestExtra { return new(test.TestExtra) }))
+
+	payload := &test.TestExtra{Key: 52, Value: []byte{0x4, 0x13}}
+	tx, err := ut.NewTransaction(5, test.TestAddress, 3, test.TestAddress2, payload)
+	req.NoError(err)
+	assr.Equal(TransactionType(5), tx.Type)
+	assr.Equal(test.TestAddress, tx.From)
+	assr.Equal(uint64(3), tx.Nonce)
+	assr.Equal(test.TestAddress2, tx.To)
+
+	// Signing and round-tripping keeps the payload intact
+	req.NoError(ut.SignTransaction(tx, test.TestPrivateKey))
+	txx, err := ut.ExtendTransaction(tx)
+	req.NoError(err)
+	tx_, err := ut.TransactionExtFromBytes(txx.Bytes)
+	req.NoError(err)
+	p, err := ut.DecodeTransactionData(tx_.Transaction)
+	req.NoError(err)
+	assr.Equal(payload, p)
+
+	// Unregistered type
+	_, err = ut.NewTransaction(6, test.TestAddress, 3, test.TestAddress2, payload)
+	assr.ErrorIs(err, ErrUnknownDataType)
+	tx.Type = 6
+	_, err = ut.DecodeTransactionData(tx)
+	assr.ErrorIs(err, ErrUnknownDataType)
+
+	// Wrong payload type
+	_, err = ut.NewTransaction(5, test.TestAddress, 3, test.TestAddress2, &test.TransactionNoSig{})
+	assr.ErrorIs(err, ErrPayloadTypeMismatch)
+
+	// Malformed Data
+	tx.Type = 5
+	tx.Data = []byte{0x21, 0x9, 0x09}
+	_, err = ut.DecodeTransactionData(tx)
+	assr.ErrorIs(err, ErrDecodeData)
+	tx.Data = append(append([]byte{}, txx.Data...), 0x0)
+	_, err = ut.DecodeTransactionData(tx)
+	assr.ErrorIs(err, ErrDecodeData)
+}
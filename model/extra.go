@@ -0,0 +1,167 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/daotl/go-marsha"
+)
+
+var (
+	ErrUnknownExtraType = errors.New("no ExtraCtor registered for Extra")
+	ErrDecodeExtra      = errors.New("failed to decode Extra")
+)
+
+// extraMtxs guard ExtraUnmarshaled of the Ext models when it's lazily decoded by LoadExtra. They
+// are kept out of the models so the models can still be copied, and are picked by the addresses of
+// the models.
+var extraMtxs [64]sync.RWMutex
+
+// extraMtx returns the lock guarding ExtraUnmarshaled of the Ext model at `p`.
+func extraMtx(p unsafe.Pointer) *sync.RWMutex {
+	return &extraMtxs[(uintptr(p)>>4)%uintptr(len(extraMtxs))]
+}
+
+// AsExtraCtor converts an ExtraCtor of a concrete type into ExtraCtor[ExtraPtr] for registering.
+func AsExtraCtor[T ExtraPtr](ctor ExtraCtor[T]) ExtraCtor[ExtraPtr] {
+	return func() ExtraPtr { return ctor() }
+}
+
+// RegisterTransactionExtra registers the ExtraCtor used to decode Transaction.Extra of the
+// transactions of type `t` into TransactionExt.ExtraUnmarshaled.
+//
+// Once any ExtraCtor is registered, extending or reading a transaction with non-empty Extra of
+// an unregistered type fails with ErrUnknownExtraType.
+//
+// Registering should be done before using the Util concurrently.
+func (u *Util) RegisterTransactionExtra(t TransactionType, ctor ExtraCtor[ExtraPtr]) {
+	u.txExtraCtors[t] = ctor
+	u.txExtraRegistered = true
+}
+
+// RegisterBlockHeaderExtra registers the ExtraCtor used to decode BlockHeader.Extra into
+// BlockHeaderExt.ExtraUnmarshaled.
+//
+// Once registered, extending or reading a block header with non-empty Extra that can't be decoded
+// fails with ErrDecodeExtra.
+//
+// Registering should be done before using the Util concurrently.
+func (u *Util) RegisterBlockHeaderExtra(ctor ExtraCtor[ExtraPtr]) {
+	u.bhExtraCtor = ctor
+}
+
+// LoadExtra returns TransactionExt.ExtraUnmarshaled. If it's not set yet, Transaction.Extra is
+// decoded using the ExtraCtor registered in `u` and cached in TransactionExt.ExtraUnmarshaled,
+// which is then also counted by TransactionExt.Size. A nil result (empty Extra or no ExtraCtor)
+// is cached too, while a decoding error is not.
+//
+// It's safe to call LoadExtra concurrently on the same TransactionExt.
+func (txx *TransactionExt) LoadExtra(u *Util) (ExtraPtr, error) {
+	mtx := extraMtx(unsafe.Pointer(txx))
+	mtx.RLock()
+	extra, decoded := txx.ExtraUnmarshaled, txx.extraDecoded
+	mtx.RUnlock()
+	if extra != nil || decoded {
+		return extra, nil
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if txx.ExtraUnmarshaled == nil && !txx.extraDecoded {
+		extra, err := u.DecodeTransactionExtra(txx.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		txx.ExtraUnmarshaled, txx.extraDecoded = extra, true
+	}
+	return txx.ExtraUnmarshaled, nil
+}
+
+// LoadExtra returns BlockHeaderExt.ExtraUnmarshaled. If it's not set yet, BlockHeader.Extra is
+// decoded using the ExtraCtor registered in `u` and cached in BlockHeaderExt.ExtraUnmarshaled,
+// which is then also counted by BlockHeaderExt.Size. A nil result (empty Extra or no ExtraCtor)
+// is cached too, while a decoding error is not.
+//
+// It's safe to call LoadExtra concurrently on the same BlockHeaderExt.
+func (bhx *BlockHeaderExt) LoadExtra(u *Util) (ExtraPtr, error) {
+	mtx := extraMtx(unsafe.Pointer(bhx))
+	mtx.RLock()
+	extra, decoded := bhx.ExtraUnmarshaled, bhx.extraDecoded
+	mtx.RUnlock()
+	if extra != nil || decoded {
+		return extra, nil
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if bhx.ExtraUnmarshaled == nil && !bhx.extraDecoded {
+		extra, err := u.DecodeBlockHeaderExtra(bhx.BlockHeader)
+		if err != nil {
+			return nil, err
+		}
+		bhx.ExtraUnmarshaled, bhx.extraDecoded = extra, true
+	}
+	return bhx.ExtraUnmarshaled, nil
+}
+
+// eagerTransactionExtra decodes Transaction.Extra when extending or reading a Transaction, it
+// returns nil if Util.LazyExtra is set.
+func (u *Util) eagerTransactionExtra(tx *Transaction) (ExtraPtr, error) {
+	if u.LazyExtra {
+		return nil, nil
+	}
+	return u.DecodeTransactionExtra(tx)
+}
+
+// eagerBlockHeaderExtra decodes BlockHeader.Extra when extending or reading a BlockHeader, it
+// returns nil if Util.LazyExtra is set.
+func (u *Util) eagerBlockHeaderExtra(bh *BlockHeader) (ExtraPtr, error) {
+	if u.LazyExtra {
+		return nil, nil
+	}
+	return u.DecodeBlockHeaderExtra(bh)
+}
+
+// DecodeTransactionExtra decodes Transaction.Extra using the ExtraCtor registered for the
+// transaction type, it returns nil if Extra is empty or no ExtraCtor is registered at all.
+func (u *Util) DecodeTransactionExtra(tx *Transaction) (ExtraPtr, error) {
+	if len(tx.Extra) == 0 || !u.txExtraRegistered {
+		return nil, nil
+	}
+	ctor := u.txExtraCtors[tx.Type]
+	if ctor == nil {
+		return nil, fmt.Errorf("%w: transaction type %d", ErrUnknownExtraType, tx.Type)
+	}
+	return u.decodeExtra(ctor, tx.Extra)
+}
+
+// DecodeBlockHeaderExtra decodes BlockHeader.Extra using the registered ExtraCtor,
+// it returns nil if Extra is empty or no ExtraCtor is registered.
+func (u *Util) DecodeBlockHeaderExtra(bh *BlockHeader) (ExtraPtr, error) {
+	if len(bh.Extra) == 0 || u.bhExtraCtor == nil {
+		return nil, nil
+	}
+	return u.decodeExtra(u.bhExtraCtor, bh.Extra)
+}
+
+// decodeExtra decodes `extra` into a new instance created by `ctor`.
+func (u *Util) decodeExtra(ctor ExtraCtor[ExtraPtr], extra []byte) (ExtraPtr, error) {
+	p := ctor()
+	if err := u.unmarshalWhole(extra, p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecodeExtra, err)
+	}
+	return p, nil
+}
+
+// unmarshalWhole unmarshals `bin` into `p`, it fails if `bin` is not consumed entirely.
+func (u *Util) unmarshalWhole(bin []byte, p marsha.StructPtr) error {
+	n, err := u.Mrsh.UnmarshalStruct(bin, p)
+	if err != nil {
+		return err
+	} else if n >= 0 && n != len(bin) {
+		return fmt.Errorf("%d trailing bytes", len(bin)-n)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package model_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestExtraRegistry(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	ut := New(test.Mrsh, test.Crpt)
+	ut.RegisterTransactionExtra(5, AsExtraCtor(func() *test.TestExtra { return new(test.TestExtra) }))
+	ut.RegisterBlockHeaderExtra(AsExtraCtor(func() *test.TestExtra { return new(test.TestExtra) }))
+
+	extra := &test.TestExtra{Key: 52, Value: []byte{0x4, 0x13}}
+	extraBytes, err := ut.Mrsh.MarshalStruct(extra)
+	req.NoError(err)
+
+	t.Run("Transaction", func(t *testing.T) {
+		tx := *test.GenRandomTransaction()
+		tx.Type = 5
+		tx.Extra = extraBytes
+		txx, err := ut.ExtendTransaction(&tx)
+		req.NoError(err)
+		assr.Equal(extra, txx.ExtraUnmarshaled)
+
+		txx_, _, err := ut.ReadTransactionExtFrom(bytes.NewReader(txx.Bytes))
+		req.NoError(err)
+		assr.Equal(extra, txx_.ExtraUnmarshaled)
+
+		// Empty Extra
+		tx.Extra = nil
+		txx, err = ut.ExtendTransaction(&tx)
+		req.NoError(err)
+		assr.Nil(txx.ExtraUnmarshaled)
+
+		// Unknown type
+		tx.Type = 6
+		tx.Extra = extraBytes
+		_, err = ut.ExtendTransaction(&tx)
+		assr.ErrorIs(err, ErrUnknownExtraType)
+
+		// Decode failure
+		tx.Type = 5
+		tx.Extra = []byte{0x21, 0x9, 0x09}
+		_, err = ut.ExtendTransaction(&tx)
+		assr.ErrorIs(err, ErrDecodeExtra)
+
+		// Trailing bytes
+		tx.Extra = append(append([]byte{}, extraBytes...), 0x0)
+		_, err = ut.ExtendTransaction(&tx)
+		assr.ErrorIs(err, ErrDecodeExtra)
+
+		// Nothing is decoded if no ExtraCtor is registered
+		txx, err = test.Util.ExtendTransaction(&tx)
+		req.NoError(err)
+		assr.Nil(txx.ExtraUnmarshaled)
+	})
+
+	t.Run("BlockHeader", func(t *testing.T) {
+		bx := test.GenSignedBlock(nil, 1, 0, 0)
+		bh := *bx.Header.BlockHeader
+		bh.Extra = extraBytes
+		bhx, err := ut.ExtendBlockHeader(&bh)
+		req.NoError(err)
+		assr.Equal(extra, bhx.ExtraUnmarshaled)
+
+		bx, err = ut.ExtendBlock(&Block{Header: &bh})
+		req.NoError(err)
+		var buf bytes.Buffer
+		_, err = bx.WriteTo(&buf)
+		req.NoError(err)
+		bx_, _, err := ut.ReadBlockExtFrom(&buf)
+		req.NoError(err)
+		assr.Equal(extra, bx_.Header.ExtraUnmarshaled)
+
+		bh.Extra = []byte{0x21, 0x9, 0x09}
+		_, err = ut.ExtendBlockHeader(&bh)
+		assr.ErrorIs(err, ErrDecodeExtra)
+	})
+}
+
+func TestLazyExtra(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	ut := New(test.Mrsh, test.Crpt)
+	ut.LazyExtra = true
+	ut.RegisterTransactionExtra(5, AsExtraCtor(func() *test.TestExtra { return new(test.TestExtra) }))
+	ut.RegisterBlockHeaderExtra(AsExtraCtor(func() *test.TestExtra { return new(test.TestExtra) }))
+
+	extra := &test.TestExtra{Key: 52, Value: []byte{0x4, 0x13}}
+	extraBytes, err := ut.Mrsh.MarshalStruct(extra)
+	req.NoError(err)
+
+	t.Run("Transaction", func(t *testing.T) {
+		tx := *test.GenRandomTransaction()
+		tx.Type = 5
+		tx.Extra = extraBytes
+		txx, err := ut.ExtendTransaction(&tx)
+		req.NoError(err)
+		assr.Nil(txx.ExtraUnmarshaled)
+		size := txx.Size()
+
+		var wg sync.WaitGroup
+		extras := make([]ExtraPtr, 8)
+		for i := range extras {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				extras[i], _ = txx.LoadExtra(ut)
+			}(i)
+		}
+		wg.Wait()
+		for _, e := range extras {
+			assr.Equal(extra, e)
+			assr.Same(extras[0], e) // Decoded only once
+		}
+		assr.Equal(size+extra.Size(), txx.Size())
+
+		// Decode failure isn't cached
+		tx.Extra = []byte{0x21, 0x9, 0x09}
+		txx, err = ut.ExtendTransaction(&tx)
+		req.NoError(err)
+		_, err = txx.LoadExtra(ut)
+		assr.ErrorIs(err, ErrDecodeExtra)
+		assr.Nil(txx.ExtraUnmarshaled)
+	})
+
+	t.Run("BlockHeader", func(t *testing.T) {
+		bh := *test.GenSignedBlock(nil, 1, 0, 0).Header.BlockHeader
+		bh.Extra = extraBytes
+		bin, err := ut.Mrsh.MarshalStruct(&bh)
+		req.NoError(err)
+		bhx, _, err := ut.ReadBlockHeaderExtFrom(bytes.NewReader(bin))
+		req.NoError(err)
+		assr.Nil(bhx.ExtraUnmarshaled)
+		size := bhx.Size()
+
+		e, err := bhx.LoadExtra(ut)
+		req.NoError(err)
+		assr.Equal(extra, e)
+		assr.Same(e, bhx.ExtraUnmarshaled)
+		assr.Equal(size+extra.Size(), bhx.Size())
+	})
+
+	t.Run("Nil result cached", func(t *testing.T) {
+		ut := New(test.Mrsh, test.Crpt)
+		tx := *test.GenRandomTransaction()
+		tx.Type = 5
+		tx.Extra = extraBytes
+		txx, err := ut.ExtendTransaction(&tx)
+		req.NoError(err)
+		e, err := txx.LoadExtra(ut)
+		req.NoError(err)
+		assr.Nil(e)
+
+		// Not decoded again after an ExtraCtor is registered
+		ut.RegisterTransactionExtra(5, AsExtraCtor(func() *test.TestExtra { return new(test.TestExtra) }))
+		e, err = txx.LoadExtra(ut)
+		req.NoError(err)
+		assr.Nil(e)
+	})
+}
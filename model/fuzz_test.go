@@ -0,0 +1,173 @@
+package model_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/daotl/go-marsha"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+// Fuzz targets for the decoding entry points. They check that decoding arbitrary input never
+// panics, that decode -> encode -> decode is stable, and that the reported number of bytes read
+// matches the bytes consumed. Run them with e.g.:
+//
+//	go test ./model -run '^$' -fuzz FuzzTransaction -fuzztime 30s
+
+// blockBytes returns the CBOR encoded block.
+func blockBytes(t testing.TB, bx *BlockExt) []byte {
+	var buf bytes.Buffer
+	_, err := bx.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+// assertStable asserts that `p` is encoded into `bin`, and decoding `bin` into `p_` then encoding it
+// again results in the same bytes.
+func assertStable[T any, P interface {
+	*T
+	marsha.StructPtr
+}](t *testing.T, p P, bin []byte) {
+	ut := test.Util
+	bin_, err := ut.Mrsh.MarshalStruct(p)
+	require.NoError(t, err)
+	assert.Equal(t, bin, bin_)
+
+	p_ := P(new(T))
+	n, err := ut.Mrsh.UnmarshalStruct(bin, p_)
+	require.NoError(t, err)
+	assert.Equal(t, len(bin), n)
+	bin__, err := ut.Mrsh.MarshalStruct(p_)
+	require.NoError(t, err)
+	assert.Equal(t, bin, bin__)
+}
+
+func FuzzTransaction(f *testing.F) {
+	ut := test.Util
+	f.Add(test.GenRandomTransactionExt().Bytes)
+	for _, txx := range test.GenSignedBlock(nil, 1, 0, 3).Txs {
+		f.Add(txx.Bytes)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx := new(Transaction)
+		n, err := ut.Mrsh.UnmarshalStruct(data, tx)
+		if err != nil {
+			return
+		}
+		assertStable(t, tx, data[:n])
+
+		txx, read, err := ut.ReadTransactionExtFrom(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, int64(n), read)
+		assert.Equal(t, data[:n], txx.Bytes)
+		assert.Equal(t, tx, txx.Transaction)
+
+		txx_, err := ut.TransactionExtFromBytes(data)
+		require.NoError(t, err)
+		assert.Equal(t, txx.Hash, txx_.Hash)
+	})
+}
+
+func FuzzBlockHeader(f *testing.F) {
+	ut := test.Util
+	f.Add(test.GenSignedBlock([]BlockHash{test.TestHash}, 1, 0, 1).Header.Bytes)
+	for _, bhx := range test.GenRandomBlockHeaderExts(3, 3, 2, []byte{0x4, 0x13}) {
+		f.Add(bhx.Bytes)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bh := new(BlockHeader)
+		n, err := ut.Mrsh.UnmarshalStruct(data, bh)
+		if err != nil {
+			return
+		}
+		assertStable(t, bh, data[:n])
+
+		bhx, read, err := ut.ReadBlockHeaderExtFrom(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, int64(n), read)
+		assert.Equal(t, data[:n], bhx.Bytes)
+		assert.Equal(t, bh, bhx.BlockHeader)
+
+		bhx_, err := ut.BlockHeaderExtFromBytes(data)
+		require.NoError(t, err)
+		assert.Equal(t, bhx.Hash, bhx_.Hash)
+	})
+}
+
+func FuzzBlock(f *testing.F) {
+	ut := test.Util
+	f.Add(blockBytes(f, test.GenSignedBlock([]BlockHash{test.TestHash}, 1, 0, 3)))
+	f.Add(blockBytes(f, test.GenSignedBlock(nil, 0, 0, 0)))
+	f.Add(blockBytes(f, test.GenRandomBlock(2, nil, 1)))
+	f.Add([]byte{})
+	f.Add([]byte{BlockCborInitial})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// The functions below may fail on the data the others accept, e.g., ReadBlockExtFrom
+		// accepts both CBOR null and empty array for no transaction, but they shouldn't panic.
+		_, _, _ = ut.ReadBlockHeaderExtFromBlockStream(bytes.NewReader(data))
+		_, _ = ut.ExtractBlockHeaderExtFromBlockBytes(data)
+		_, _ = ut.BlockExtFromBytes(data)
+
+		b := new(Block)
+		n, err := ut.Mrsh.UnmarshalStruct(data, b)
+		if err == nil {
+			assertStable(t, b, data[:n])
+		}
+
+		bx, read, err := ut.ReadBlockExtFrom(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// The block initial byte is not checked and no transaction can be encoded as either CBOR
+		// null or empty array in non-strict mode, so only the header bytes are compared.
+		hlen := BlockCborInitialLength + len(bx.Header.Bytes)
+		assert.Equal(t, data[BlockCborInitialLength:hlen], bx.Header.Bytes)
+		bin := blockBytes(t, bx)
+		assert.Equal(t, read, int64(len(bin)))
+
+		bx_, read_, err := ut.ReadBlockExtFrom(bytes.NewReader(bin))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(bin)), read_)
+		assert.Equal(t, bin, blockBytes(t, bx_))
+		assert.Equal(t, bx.Header.Hash, bx_.Header.Hash)
+		assert.Equal(t, len(bx.Txs), len(bx_.Txs))
+		for i := range bx.Txs {
+			assert.Equal(t, bx.Txs[i].Hash, bx_.Txs[i].Hash)
+		}
+	})
+}
+
+func FuzzTransactionSlice(f *testing.F) {
+	ut := test.Util
+	for _, txCount := range []int{0, 1, 3} {
+		bx := test.GenSignedBlock(nil, 1, 0, txCount)
+		var buf bytes.Buffer
+		_, err := ut.WriteMarshalTransactionExtSliceTo(bx.Txs, &buf)
+		require.NoError(f, err)
+		f.Add(buf.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		txxs, n, err := ut.ReadTransactionExtSliceFrom(bytes.NewReader(data))
+		if err != nil {
+			_, _ = ut.TransactionExtSliceFromTransactionsBytes(data)
+			return
+		}
+		var buf bytes.Buffer
+		_, err = ut.WriteMarshalTransactionExtSliceTo(txxs, &buf)
+		require.NoError(t, err)
+		if len(data) > 0 && data[0] != CborNull {
+			assert.Equal(t, data[:n], buf.Bytes())
+			txxs_, err := ut.TransactionExtSliceFromTransactionsBytes(data[:n])
+			require.NoError(t, err)
+			assert.Equal(t, txxs, txxs_)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/crpt/go-crpt"
+)
+
+// TransactionTypeGenesis is the reserved TransactionType of the genesis transaction which creates
+// a ledger, its Transaction.Data is an encoded GenesisPayload.
+const TransactionTypeGenesis TransactionType = math.MaxUint8
+
+var ErrInvalidGenesis = errors.New("invalid genesis")
+
+// NewLedger creates a genesis transaction with `payload` signed by `priv`, and returns it with the
+// LedgerID of the created ledger, which is the hash of the genesis transaction.
+//
+// If GenesisPayload.Params is set, it should match the Crpt used by `u`, see CheckLedgerParams.
+// The genesis transaction signature is not bound to any ledger regardless of Util.LedgerID,
+// set Util.LedgerID to the returned LedgerID to work with the created ledger.
+func (u *Util) NewLedger(priv crpt.PrivateKey, payload *GenesisPayload) (*TransactionExt, LedgerID, error) {
+	if len(payload.Creators) == 0 {
+		return nil, nil, fmt.Errorf("%w: no creator", ErrInvalidGenesis)
+	}
+	if payload.Params != nil {
+		if err := u.CheckLedgerParams(payload.Params); err != nil {
+			return nil, nil, err
+		}
+	}
+	tx, err := u.NewTransaction(TransactionTypeGenesis, priv.Public().Address(), 0, nil, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = u.SignTransaction(tx, priv); err != nil {
+		return nil, nil, err
+	}
+	txx, err := u.ExtendTransaction(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return txx, txx.Hash, nil
+}
+
+// VerifyGenesis checks that `txx` is a valid genesis transaction, and returns its GenesisPayload
+// and the LedgerID of the ledger it creates. It fails with ErrInvalidGenesis if not.
+func (u *Util) VerifyGenesis(txx *TransactionExt) (*GenesisPayload, LedgerID, error) {
+	if txx.Type != TransactionTypeGenesis {
+		return nil, nil, fmt.Errorf("%w: transaction type %d", ErrInvalidGenesis, txx.Type)
+	}
+	if txx.Nonce != 0 || len(txx.To) != 0 || len(txx.Extra) != 0 {
+		return nil, nil, fmt.Errorf("%w: Nonce, To and Extra should be empty", ErrInvalidGenesis)
+	}
+	p, err := u.DecodeTransactionData(txx.Transaction)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidGenesis, err)
+	}
+	payload := p.(*GenesisPayload)
+	if len(payload.Creators) == 0 {
+		return nil, nil, fmt.Errorf("%w: no creator", ErrInvalidGenesis)
+	}
+	// The signature is not bound to any ledger, see SignatureDomainVersion
+	if ok, err := u.verifyExtSignature(nil, txx.Bytes, txx.Sig, txx.From); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidGenesis, err)
+	} else if !ok {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidGenesis, ErrInvalidSignature)
+	}
+	return payload, txx.Hash, nil
+}
+
+// NewGenesisBlock creates the first block of the ledger created by the genesis transaction `txx`,
+// which contains only `txx` and has Height 0 and no PrevHashes. The block is created at `time` by
+// `priv`, whose address should be one of GenesisPayload.Creators.
+//
+// The block header signature is bound to Util.LedgerID as usual, which should be set to the
+// LedgerID returned by NewLedger or VerifyGenesis.
+func (u *Util) NewGenesisBlock(txx *TransactionExt, time Timestamp, priv crpt.PrivateKey) (*BlockExt, error) {
+	payload, _, err := u.VerifyGenesis(txx)
+	if err != nil {
+		return nil, err
+	}
+	creator := priv.Public().Address()
+	if !containsAddress(payload.Creators, creator) {
+		return nil, fmt.Errorf("%w: %s is not a creator", ErrInvalidGenesis, creator)
+	}
+
+	txxs := TransactionExtSlice{txx}
+	bh := &BlockHeader{
+		Creator: creator,
+		Time:    time,
+		Height:  0,
+		TxRoot:  u.GenRootHashFromTransactionExtSlice(txxs),
+		TxCount: 1,
+		AppHash: payload.AppHash,
+	}
+	if err = u.SignBlockHeader(bh, priv); err != nil {
+		return nil, err
+	}
+	bhx, err := u.ExtendBlockHeader(bh)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockExt{util: u, Header: bhx, Txs: txxs}, nil
+}
+
+func containsAddress(addrs [][]byte, addr Address) bool {
+	for _, a := range addrs {
+		if string(a) == string(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestGenesis(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	ut := New(test.Mrsh, test.Crpt)
+	payload := &GenesisPayload{
+		AppHash:  test.TestHash,
+		Params:   &LedgerParams{Version: 1, MaxTxBytes: 1 << 16},
+		Creators: [][]byte{test.TestAddress, test.TestAddress2},
+	}
+	txx, ledgerID, err := ut.NewLedger(test.TestPrivateKey, payload)
+	req.NoError(err)
+	assr.Equal(TransactionTypeGenesis, txx.Type)
+	assr.Equal(test.TestAddress, txx.From)
+	assr.Equal(txx.Hash, ledgerID)
+
+	// The genesis transaction isn't bound to any ledger
+	ledgerUt := New(test.Mrsh, test.Crpt)
+	ledgerUt.LedgerID = ledgerID
+	for _, u := range []*Util{ut, ledgerUt} {
+		payload_, ledgerID_, err := u.VerifyGenesis(txx)
+		req.NoError(err)
+		assr.Equal(payload, payload_)
+		assr.Equal(ledgerID, ledgerID_)
+	}
+	p, err := ut.DecodeTransactionData(txx.Transaction)
+	req.NoError(err)
+	assr.Equal(payload, p)
+
+	_, _, err = ut.NewLedger(test.TestPrivateKey, &GenesisPayload{})
+	assr.ErrorIs(err, ErrInvalidGenesis)
+
+	t.Run("Invalid genesis", func(t *testing.T) {
+		for _, modify := range []func(tx *Transaction){
+			func(tx *Transaction) { tx.Type = 0 },
+			func(tx *Transaction) { tx.Nonce = 1 },
+			func(tx *Transaction) { tx.To = test.TestAddress2 },
+			func(tx *Transaction) { tx.Data = tx.Data[1:] },
+			func(tx *Transaction) { tx.Sig = append(Signature{}, tx.Sig...); tx.Sig[0]++ },
+		} {
+			tx := *txx.Transaction
+			modify(&tx)
+			txx_, err := ut.ExtendTransaction(&tx)
+			req.NoError(err)
+			_, _, err = ut.VerifyGenesis(txx_)
+			assr.ErrorIs(err, ErrInvalidGenesis)
+		}
+	})
+
+	t.Run("Genesis block", func(t *testing.T) {
+		bx, err := ledgerUt.NewGenesisBlock(txx, 1525392000, test.TestPrivateKey)
+		req.NoError(err)
+		assr.Equal(BlockHeight(0), bx.Header.Height)
+		assr.Empty(bx.Header.PrevHashes)
+		assr.Equal(payload.AppHash, bx.Header.AppHash)
+		assr.Equal(TransactionExtSlice{txx}, bx.Txs)
+		assr.NoError(ledgerUt.ValidateBlockExt(bx, ValidateOptions{}))
+
+		// Header signature is bound to the ledger
+		ok, err := ut.VerifyBlockHeaderExtSignature(bx.Header)
+		req.NoError(err)
+		assr.False(ok)
+
+		_, priv, err := test.Crpt.GenerateKey(nil)
+		req.NoError(err)
+		_, err = ledgerUt.NewGenesisBlock(txx, 1525392000, priv)
+		assr.ErrorIs(err, ErrInvalidGenesis)
+	})
+
+	t.Run("No replay on other ledgers", func(t *testing.T) {
+		otherUt := New(test.Mrsh, test.Crpt)
+		otherUt.LedgerID = test.TestHash
+		for _, u := range []*Util{ledgerUt, otherUt} {
+			ok, err := u.VerifyTransactionExtSignature(txx)
+			assr.ErrorIs(err, ErrInvalidGenesis)
+			assr.False(ok)
+			ok, err = u.VerifyTransactionSignature(txx.Transaction)
+			assr.ErrorIs(err, ErrInvalidGenesis)
+			assr.False(ok)
+			assr.Equal([]int{1}, u.VerifyTransactionExtSlice(TransactionExtSlice{signedTransactionExt(t, u), txx}))
+		}
+		// Without LedgerID, the signature can still be verified as usual
+		ok, err := ut.VerifyTransactionExtSignature(txx)
+		req.NoError(err)
+		assr.True(ok)
+
+		// Not in blocks other than the genesis block
+		for _, u := range []*Util{ledgerUt, otherUt} {
+			bh := &BlockHeader{
+				Creator: test.TestAddress,
+				Height:  5,
+				TxRoot:  u.GenRootHashFromTransactionExtSlice(TransactionExtSlice{txx}),
+				TxCount: 1,
+			}
+			req.NoError(u.SignBlockHeader(bh, test.TestPrivateKey))
+			bhx, err := u.ExtendBlockHeader(bh)
+			req.NoError(err)
+			bx := &BlockExt{Header: bhx, Txs: TransactionExtSlice{txx}}
+			assr.ErrorIs(u.ValidateBlockExt(bx, ValidateOptions{}), ErrInvalidGenesis)
+		}
+
+		// Not in the genesis block of another ledger
+		bx, err := ledgerUt.NewGenesisBlock(txx, 1525392000, test.TestPrivateKey)
+		req.NoError(err)
+		req.NoError(otherUt.SignBlockHeaderExt(bx.Header, test.TestPrivateKey))
+		assr.ErrorIs(otherUt.ValidateBlockExt(bx, ValidateOptions{}), ErrInvalidGenesis)
+	})
+}
+
+// signedTransactionExt creates a normal transaction signed by TestPrivateKey with `u`.
+func signedTransactionExt(t *testing.T, u *Util) *TransactionExt {
+	tx := test.GenRandomTransaction()
+	require.NoError(t, u.SignTransaction(tx, test.TestPrivateKey))
+	txx, err := u.ExtendTransaction(tx)
+	require.NoError(t, err)
+	return txx
+}
@@ -0,0 +1,367 @@
+package model
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Canonical JSON representation of the models:
+//
+//   - Byte arrays (addresses, hashes, signatures, etc.) are encoded as lowercase hex strings
+//     prefixed with "0x".
+//   - uint64 numbers are encoded as decimal strings, so they won't lose precision in JavaScript.
+//   - Ext models also include the hashes computed from the CBOR encoded bytes.
+//
+// Models decoded from their canonical JSON representation are marshaled into the same CBOR bytes
+// as the original ones.
+
+var (
+	ErrInvalidHexString   = errors.New("invalid 0x-prefixed hex string")
+	ErrJSONHashMismatch   = errors.New("hash in JSON doesn't match the computed one")
+	ErrMissingTransaction = errors.New("transaction is missing")
+)
+
+// hexBytes is a byte slice encoded into JSON as a 0x-prefixed lowercase hex string.
+type hexBytes []byte
+
+func (bz hexBytes) MarshalJSON() ([]byte, error) {
+	s := make([]byte, 2*len(bz)+4)
+	copy(s, `"0x`)
+	hex.Encode(s[3:], bz)
+	s[len(s)-1] = '"'
+	return s, nil
+}
+
+func (bz *hexBytes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*bz = nil
+		return nil
+	}
+	if len(data) < 4 || data[0] != '"' || data[len(data)-1] != '"' ||
+		!bytes.HasPrefix(data[1:], []byte("0x")) {
+		return fmt.Errorf("%w: %s", ErrInvalidHexString, data)
+	}
+	src := data[3 : len(data)-1]
+	b := make([]byte, hex.DecodedLen(len(src)))
+	if _, err := hex.Decode(b, src); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHexString, err)
+	}
+	*bz = b
+	return nil
+}
+
+func hexBytesSlice(bzs [][]byte) []hexBytes {
+	if bzs == nil {
+		return nil
+	}
+	hs := make([]hexBytes, len(bzs))
+	for i, bz := range bzs {
+		hs[i] = bz
+	}
+	return hs
+}
+
+func bytesSlice(hs []hexBytes) [][]byte {
+	if hs == nil {
+		return nil
+	}
+	bzs := make([][]byte, len(hs))
+	for i, h := range hs {
+		bzs[i] = h
+	}
+	return bzs
+}
+
+type transactionJSON struct {
+	Type  TransactionType `json:"type"`
+	From  hexBytes        `json:"from"`
+	Nonce uint64          `json:"nonce,string"`
+	To    hexBytes        `json:"to,omitempty"`
+	Data  hexBytes        `json:"data,omitempty"`
+	Extra hexBytes        `json:"extra,omitempty"`
+	Sig   hexBytes        `json:"signature,omitempty"`
+}
+
+func (tj *transactionJSON) from(tx *Transaction) {
+	*tj = transactionJSON{
+		Type:  tx.Type,
+		From:  hexBytes(tx.From),
+		Nonce: tx.Nonce,
+		To:    hexBytes(tx.To),
+		Data:  hexBytes(tx.Data),
+		Extra: hexBytes(tx.Extra),
+		Sig:   hexBytes(tx.Sig),
+	}
+}
+
+func (tj *transactionJSON) to(tx *Transaction) {
+	*tx = Transaction{
+		Type:  tj.Type,
+		From:  Address(tj.From),
+		Nonce: tj.Nonce,
+		To:    Address(tj.To),
+		Data:  tj.Data,
+		Extra: tj.Extra,
+		Sig:   Signature(tj.Sig),
+	}
+}
+
+// MarshalJSON implements json.Marshaler with the canonical JSON representation.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	var tj transactionJSON
+	tj.from(&t)
+	return json.Marshal(&tj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the canonical JSON representation.
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	var tj transactionJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return err
+	}
+	tj.to(t)
+	return nil
+}
+
+type transactionExtJSON struct {
+	transactionJSON
+	Hash hexBytes `json:"hash"`
+}
+
+// MarshalJSON implements json.Marshaler with the canonical JSON representation, which is the same
+// as Transaction's with the additional "hash" field.
+func (txx TransactionExt) MarshalJSON() ([]byte, error) {
+	var tj transactionExtJSON
+	tj.transactionJSON.from(txx.Transaction)
+	tj.Hash = hexBytes(txx.Hash)
+	return json.Marshal(&tj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the canonical JSON representation.
+//
+// NOTE: TransactionExt.Bytes is not set and TransactionExt.Hash is not verified,
+// use Util.TransactionExtFromJSON for that.
+func (txx *TransactionExt) UnmarshalJSON(data []byte) error {
+	var tj transactionExtJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return err
+	}
+	txx.Transaction = new(Transaction)
+	tj.transactionJSON.to(txx.Transaction)
+	txx.Hash = TransactionHash(tj.Hash)
+	return nil
+}
+
+type blockHeaderJSON struct {
+	Creator    hexBytes    `json:"creator"`
+	Time       Timestamp   `json:"timestamp,string"`
+	PrevHashes []hexBytes  `json:"prevHashes,omitempty"`
+	Height     BlockHeight `json:"height,string"`
+	TxRoot     hexBytes    `json:"transactionsRoot"`
+	TxCount    uint64      `json:"transactionCount,string"`
+	AppHash    hexBytes    `json:"apphash,omitempty"`
+	Extra      hexBytes    `json:"extra,omitempty"`
+	Sig        hexBytes    `json:"signature,omitempty"`
+}
+
+func (bj *blockHeaderJSON) from(bh *BlockHeader) {
+	*bj = blockHeaderJSON{
+		Creator:    hexBytes(bh.Creator),
+		Time:       bh.Time,
+		PrevHashes: hexBytesSlice(bh.PrevHashes),
+		Height:     bh.Height,
+		TxRoot:     hexBytes(bh.TxRoot),
+		TxCount:    bh.TxCount,
+		AppHash:    hexBytes(bh.AppHash),
+		Extra:      hexBytes(bh.Extra),
+		Sig:        hexBytes(bh.Sig),
+	}
+}
+
+func (bj *blockHeaderJSON) to(bh *BlockHeader) {
+	*bh = BlockHeader{
+		Creator:    Address(bj.Creator),
+		Time:       bj.Time,
+		PrevHashes: bytesSlice(bj.PrevHashes),
+		Height:     bj.Height,
+		TxRoot:     bj.TxRoot,
+		TxCount:    bj.TxCount,
+		AppHash:    bj.AppHash,
+		Extra:      bj.Extra,
+		Sig:        Signature(bj.Sig),
+	}
+}
+
+// MarshalJSON implements json.Marshaler with the canonical JSON representation.
+func (bh BlockHeader) MarshalJSON() ([]byte, error) {
+	var bj blockHeaderJSON
+	bj.from(&bh)
+	return json.Marshal(&bj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the canonical JSON representation.
+func (bh *BlockHeader) UnmarshalJSON(data []byte) error {
+	var bj blockHeaderJSON
+	if err := json.Unmarshal(data, &bj); err != nil {
+		return err
+	}
+	bj.to(bh)
+	return nil
+}
+
+type blockHeaderExtJSON struct {
+	blockHeaderJSON
+	Hash      hexBytes `json:"hash"`
+	FullHash  hexBytes `json:"fullHash,omitempty"`
+	NoSigHash hexBytes `json:"noSigHash,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler with the canonical JSON representation, which is the same
+// as BlockHeader's with the additional "hash" field, and "fullHash" or "noSigHash" fields if set.
+func (bhx BlockHeaderExt) MarshalJSON() ([]byte, error) {
+	var bj blockHeaderExtJSON
+	bj.blockHeaderJSON.from(bhx.BlockHeader)
+	bj.Hash = hexBytes(bhx.Hash)
+	bj.FullHash = hexBytes(bhx.FullHash)
+	bj.NoSigHash = hexBytes(bhx.NoSigHash)
+	return json.Marshal(&bj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the canonical JSON representation.
+//
+// NOTE: BlockHeaderExt.Bytes is not set and the hashes are not verified,
+// use Util.BlockHeaderExtFromJSON for that.
+func (bhx *BlockHeaderExt) UnmarshalJSON(data []byte) error {
+	var bj blockHeaderExtJSON
+	if err := json.Unmarshal(data, &bj); err != nil {
+		return err
+	}
+	bhx.BlockHeader = new(BlockHeader)
+	bj.blockHeaderJSON.to(bhx.BlockHeader)
+	bhx.Hash = BlockHash(bj.Hash)
+	bhx.FullHash = BlockHash(bj.FullHash)
+	bhx.NoSigHash = BlockHash(bj.NoSigHash)
+	return nil
+}
+
+type blockExtJSON struct {
+	Header *BlockHeaderExt     `json:"header"`
+	Txs    TransactionExtSlice `json:"transactions,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler with the canonical JSON representation, which is the same
+// as Block's except that the header and transactions are in the representations of their Ext
+// models.
+func (bx BlockExt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&blockExtJSON{Header: bx.Header, Txs: bx.Txs})
+}
+
+// UnmarshalJSON implements json.Unmarshaler with the canonical JSON representation.
+//
+// If a Util is set with SetUtil, the BlockExt is extended and verified with it the same way as
+// Util.BlockExtFromJSON does.
+//
+// NOTE: Otherwise the Bytes fields are not set and the hashes are not verified,
+// use Util.BlockExtFromJSON for that.
+func (bx *BlockExt) UnmarshalJSON(data []byte) error {
+	if bx.util != nil {
+		bx_, err := bx.util.BlockExtFromJSON(data)
+		if err != nil {
+			return err
+		}
+		*bx = *bx_
+		return nil
+	}
+
+	var bj blockExtJSON
+	if err := json.Unmarshal(data, &bj); err != nil {
+		return err
+	}
+	*bx = BlockExt{Header: bj.Header, Txs: bj.Txs}
+	return nil
+}
+
+// TransactionExtFromJSON unmarshals the canonical JSON representation of TransactionExt and extends
+// it, it fails with ErrJSONHashMismatch if the hash in JSON doesn't match the computed one.
+func (u *Util) TransactionExtFromJSON(data []byte) (*TransactionExt, error) {
+	var txx TransactionExt
+	if err := json.Unmarshal(data, &txx); err != nil {
+		return nil, err
+	}
+	return u.extendTransactionFromJSON(&txx)
+}
+
+func (u *Util) extendTransactionFromJSON(txx *TransactionExt) (*TransactionExt, error) {
+	txx_, err := u.ExtendTransaction(txx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(txx.Hash, txx_.Hash) {
+		return nil, fmt.Errorf("%w: transaction hash %x, computed %x", ErrJSONHashMismatch, txx.Hash, txx_.Hash)
+	}
+	return txx_, nil
+}
+
+// BlockHeaderExtFromJSON unmarshals the canonical JSON representation of BlockHeaderExt and
+// extends it, it fails with ErrJSONHashMismatch if any hash in JSON doesn't match the computed one.
+func (u *Util) BlockHeaderExtFromJSON(data []byte) (*BlockHeaderExt, error) {
+	var bhx BlockHeaderExt
+	if err := json.Unmarshal(data, &bhx); err != nil {
+		return nil, err
+	}
+	return u.extendBlockHeaderFromJSON(&bhx)
+}
+
+func (u *Util) extendBlockHeaderFromJSON(bhx *BlockHeaderExt) (*BlockHeaderExt, error) {
+	bhx_, err := u.ExtendBlockHeader(bhx.BlockHeader)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(bhx.Hash, bhx_.Hash) {
+		return nil, fmt.Errorf("%w: block hash %x, computed %x", ErrJSONHashMismatch, bhx.Hash, bhx_.Hash)
+	}
+	if bhx.FullHash != nil {
+		if h := u.FullHashOf(bhx_); !bytes.Equal(bhx.FullHash, h) {
+			return nil, fmt.Errorf("%w: full hash %x, computed %x", ErrJSONHashMismatch, bhx.FullHash, h)
+		}
+	}
+	if bhx.NoSigHash != nil {
+		h, err := u.NoSigHashOf(bhx_)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(bhx.NoSigHash, h) {
+			return nil, fmt.Errorf("%w: no-signature hash %x, computed %x", ErrJSONHashMismatch, bhx.NoSigHash, h)
+		}
+	}
+	return bhx_, nil
+}
+
+// BlockExtFromJSON unmarshals the canonical JSON representation of BlockExt and extends it,
+// it fails with ErrJSONHashMismatch if any hash in JSON doesn't match the computed one.
+func (u *Util) BlockExtFromJSON(data []byte) (*BlockExt, error) {
+	var bj blockExtJSON
+	if err := json.Unmarshal(data, &bj); err != nil {
+		return nil, err
+	}
+	if bj.Header == nil {
+		return nil, ErrMissingBlockHeader
+	}
+	bhx, err := u.extendBlockHeaderFromJSON(bj.Header)
+	if err != nil {
+		return nil, err
+	}
+	txxs := make(TransactionExtSlice, len(bj.Txs))
+	for i, txx := range bj.Txs {
+		if txx == nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, ErrMissingTransaction)
+		}
+		if txxs[i], err = u.extendTransactionFromJSON(txx); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	return &BlockExt{util: u, Header: bhx, Txs: txxs}, nil
+}
@@ -0,0 +1,144 @@
+package model_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestJSON(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+	ut := test.Util
+
+	t.Run("Transaction", func(t *testing.T) {
+		tx := test.TestTransaction
+		bin, err := json.Marshal(tx)
+		req.NoError(err)
+
+		var m map[string]interface{}
+		req.NoError(json.Unmarshal(bin, &m))
+		assr.Equal(float64(4), m["type"])
+		assr.Equal("52", m["nonce"])
+		assr.Equal("0x041352", m["data"])
+		assr.Equal("0x"+test.TestAddress2.String(), m["to"])
+
+		var tx_ Transaction
+		req.NoError(json.Unmarshal(bin, &tx_))
+		cbor, err := ut.Mrsh.MarshalStruct(&tx)
+		req.NoError(err)
+		cbor_, err := ut.Mrsh.MarshalStruct(&tx_)
+		req.NoError(err)
+		assr.Equal(cbor, cbor_)
+
+		// Invalid hex strings
+		assr.ErrorIs(json.Unmarshal([]byte(`{"from":"041352"}`), &tx_), ErrInvalidHexString)
+		assr.ErrorIs(json.Unmarshal([]byte(`{"from":"0x04135"}`), &tx_), ErrInvalidHexString)
+		assr.Error(json.Unmarshal([]byte(`{"nonce":52}`), &tx_))
+	})
+
+	t.Run("TransactionExt", func(t *testing.T) {
+		txx, err := ut.ExtendTransaction(&test.TestTransaction)
+		req.NoError(err)
+		bin, err := json.Marshal(txx)
+		req.NoError(err)
+
+		var m map[string]interface{}
+		req.NoError(json.Unmarshal(bin, &m))
+		assr.Contains(m, "hash")
+		assr.Contains(m, "from")
+
+		// Marshaled by value
+		binVal, err := json.Marshal(*txx)
+		req.NoError(err)
+		assr.Equal(bin, binVal)
+
+		txx_, err := ut.TransactionExtFromJSON(bin)
+		req.NoError(err)
+		assr.Equal(txx.Bytes, txx_.Bytes)
+		assr.Equal(txx.Hash, txx_.Hash)
+
+		m["nonce"] = "53"
+		bin, err = json.Marshal(m)
+		req.NoError(err)
+		_, err = ut.TransactionExtFromJSON(bin)
+		assr.ErrorIs(err, ErrJSONHashMismatch)
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		bx := test.GenSignedBlock([]BlockHash{test.TestHash, test.TestHash2}, 3, 0, 5)
+		b := bx.Raw()
+		bin, err := json.Marshal(b)
+		req.NoError(err)
+
+		var m struct{ Header map[string]interface{} }
+		req.NoError(json.Unmarshal(bin, &m))
+		assr.Equal("3", m.Header["height"])
+		assr.Equal("5", m.Header["transactionCount"])
+		assr.Equal("1525392000", m.Header["timestamp"])
+
+		var b_ Block
+		req.NoError(json.Unmarshal(bin, &b_))
+		cbor, err := ut.Mrsh.MarshalStruct(b)
+		req.NoError(err)
+		cbor_, err := ut.Mrsh.MarshalStruct(&b_)
+		req.NoError(err)
+		assr.Equal(cbor, cbor_)
+	})
+
+	t.Run("BlockExt", func(t *testing.T) {
+		bx := test.GenSignedBlock([]BlockHash{test.TestHash}, 3, 0, 5)
+		bin, err := json.Marshal(bx)
+		req.NoError(err)
+
+		var m map[string]interface{}
+		req.NoError(json.Unmarshal(bin, &m))
+		header := m["header"].(map[string]interface{})
+		assr.Contains(header, "hash")
+		assr.Contains(header, "fullHash")
+		assr.NotContains(header, "noSigHash")
+		assr.Len(m["transactions"], 5)
+
+		// Marshaled by value
+		binVal, err := json.Marshal(*bx)
+		req.NoError(err)
+		assr.Equal(bin, binVal)
+		binVal, err = json.Marshal(*bx.Header)
+		req.NoError(err)
+		headerBin, err := json.Marshal(bx.Header)
+		req.NoError(err)
+		assr.Equal(headerBin, binVal)
+
+		bx_, err := ut.BlockExtFromJSON(bin)
+		req.NoError(err)
+		assr.Equal(bx.Header.Bytes, bx_.Header.Bytes)
+		assr.Equal(bx.Header.Hash, bx_.Header.Hash)
+		assr.Equal(bx.Txs, bx_.Txs)
+
+		// Extended with the Util set with SetUtil
+		var bx2 BlockExt
+		bx2.SetUtil(ut)
+		req.NoError(json.Unmarshal(bin, &bx2))
+		var buf, buf2 bytes.Buffer
+		_, err = bx.WriteTo(&buf)
+		req.NoError(err)
+		_, err = bx2.WriteTo(&buf2)
+		req.NoError(err)
+		assr.Equal(buf.Bytes(), buf2.Bytes())
+
+		// Hash computed in another BlockHashMode
+		noSigUt := New(test.Mrsh, test.Crpt)
+		noSigUt.BlockHashMode = BlockHashNoSig
+		_, err = noSigUt.BlockExtFromJSON(bin)
+		assr.ErrorIs(err, ErrJSONHashMismatch)
+
+		_, err = ut.BlockExtFromJSON([]byte(`{"transactions":[]}`))
+		assr.ErrorIs(err, ErrMissingBlockHeader)
+	})
+}
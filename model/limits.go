@@ -0,0 +1,281 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	cbg "github.com/daotl/cbor-gen"
+)
+
+var (
+	ErrTooManyTxs        = errors.New("too many transactions")
+	ErrTxTooLarge        = errors.New("transaction too large")
+	ErrHeaderTooLarge    = errors.New("block header too large")
+	ErrTooManyPrevHashes = errors.New("too many previous block hashes")
+	ErrExtraTooLarge     = errors.New("Extra too large")
+	ErrTrailingBytes     = errors.New("trailing bytes after the encoded model")
+)
+
+// maxPreallocTxs is the maximum number of transactions to preallocate space for according to the
+// CBOR array header when reading transactions, so a forged header can't force a huge allocation.
+const maxPreallocTxs = 1024
+
+// Limits specifies the limits enforced when reading the encoded models, so that untrusted input
+// can be decoded safely. Zero means no limit.
+//
+// When reading from an io.Reader, every limit is enforced as soon as the corresponding CBOR header
+// is read, independently of the others, e.g., MaxPrevHashes is effective without MaxHeaderBytes.
+type Limits struct {
+	// MaxTxCount is the maximum number of transactions in a block.
+	MaxTxCount uint64
+
+	// MaxTxBytes is the maximum size of an encoded transaction in bytes.
+	MaxTxBytes uint64
+
+	// MaxHeaderBytes is the maximum size of an encoded block header in bytes.
+	MaxHeaderBytes uint64
+
+	// MaxPrevHashes is the maximum number of previous block hashes in a block header.
+	MaxPrevHashes uint64
+
+	// MaxExtraBytes is the maximum size of Transaction.Extra and BlockHeader.Extra in bytes.
+	MaxExtraBytes uint64
+}
+
+func (l *Limits) checkTxCount(count uint64) error {
+	if l.MaxTxCount > 0 && count > l.MaxTxCount {
+		return fmt.Errorf("%w: %d, limit %d", ErrTooManyTxs, count, l.MaxTxCount)
+	}
+	return nil
+}
+
+func (l *Limits) checkTransaction(tx *Transaction, size uint64) error {
+	if l.MaxTxBytes > 0 && size > l.MaxTxBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrTxTooLarge, size, l.MaxTxBytes)
+	}
+	return l.checkExtra(tx.Extra)
+}
+
+func (l *Limits) checkBlockHeader(bh *BlockHeader, size uint64) error {
+	if l.MaxHeaderBytes > 0 && size > l.MaxHeaderBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrHeaderTooLarge, size, l.MaxHeaderBytes)
+	}
+	if err := l.checkPrevHashes(uint64(len(bh.PrevHashes))); err != nil {
+		return err
+	}
+	if err := l.checkTxCount(bh.TxCount); err != nil {
+		return err
+	}
+	return l.checkExtra(bh.Extra)
+}
+
+func (l *Limits) checkPrevHashes(count uint64) error {
+	if l.MaxPrevHashes > 0 && count > l.MaxPrevHashes {
+		return fmt.Errorf("%w: %d, limit %d", ErrTooManyPrevHashes, count, l.MaxPrevHashes)
+	}
+	return nil
+}
+
+func (l *Limits) checkExtra(extra []byte) error {
+	return l.checkExtraSize(uint64(len(extra)))
+}
+
+func (l *Limits) checkExtraSize(size uint64) error {
+	if l.MaxExtraBytes > 0 && size > l.MaxExtraBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrExtraTooLarge, size, l.MaxExtraBytes)
+	}
+	return nil
+}
+
+// Indices of the fields in the CBOR tuples of Transaction and BlockHeader checked while streaming.
+const (
+	txFieldExtra          = 5
+	headerFieldPrevHashes = 2
+	headerFieldExtra      = 7
+)
+
+// newTransactionFieldReader returns a fieldReader that checks Transaction.Extra against the limits
+// while reading a Transaction from `r`.
+func (l *Limits) newTransactionFieldReader(r io.Reader) *fieldReader {
+	if l.MaxExtraBytes == 0 {
+		return newFieldReader(r, nil)
+	}
+	return newFieldReader(r, func(field int, _ byte, extra uint64) error {
+		if field == txFieldExtra {
+			return l.checkExtraSize(extra)
+		}
+		return nil
+	})
+}
+
+// newBlockHeaderFieldReader returns a fieldReader that checks BlockHeader.PrevHashes and
+// BlockHeader.Extra against the limits while reading a BlockHeader from `r`.
+func (l *Limits) newBlockHeaderFieldReader(r io.Reader) *fieldReader {
+	if l.MaxPrevHashes == 0 && l.MaxExtraBytes == 0 {
+		return newFieldReader(r, nil)
+	}
+	return newFieldReader(r, func(field int, _ byte, extra uint64) error {
+		switch field {
+		case headerFieldPrevHashes:
+			return l.checkPrevHashes(extra)
+		case headerFieldExtra:
+			return l.checkExtraSize(extra)
+		}
+		return nil
+	})
+}
+
+// fieldReader reads from `r` and scans the CBOR tuple passing through, it calls `check` with the
+// index, major type and length of every top-level field as soon as the CBOR header of the field is
+// read, so the limits of the fields are enforced before the decoder allocates for them, even if the
+// total size is not limited.
+//
+// It stops scanning at the end of the tuple or at any CBOR not used by the models (e.g., maps, tags
+// and indefinite lengths), and leaves them to the decoder.
+type fieldReader struct {
+	r     io.Reader
+	check func(field int, maj byte, extra uint64) error
+	err   error    // error returned by `check`
+	done  bool     // whether scanning is stopped
+	hdr   []byte   // bytes of the CBOR header being read
+	skip  uint64   // remaining bytes of the string being read
+	items []uint64 // remaining items of the open arrays, the first of which is the tuple
+	field int      // index of the next field in the tuple
+}
+
+// newFieldReader returns a fieldReader reading from `r`, which doesn't scan if `check` is nil.
+func newFieldReader(r io.Reader, check func(field int, maj byte, extra uint64) error) *fieldReader {
+	return &fieldReader{r: r, check: check, done: check == nil, hdr: make([]byte, 0, 9)}
+}
+
+func (f *fieldReader) Read(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	n, err := f.r.Read(p)
+	if !f.done {
+		if f.err = f.scan(p[:n]); f.err != nil {
+			return 0, f.err
+		}
+	}
+	return n, err
+}
+
+func (f *fieldReader) scan(b []byte) error {
+	for len(b) > 0 && !f.done {
+		if f.skip > 0 {
+			k := f.skip
+			if k > uint64(len(b)) {
+				k = uint64(len(b))
+			}
+			f.skip -= k
+			b = b[k:]
+			continue
+		}
+
+		f.hdr = append(f.hdr, b[0])
+		b = b[1:]
+		var size int
+		switch info := f.hdr[0] & 0x1f; {
+		case info < 24:
+			size = 1
+		case info <= 27:
+			size = 1 + 1<<(info-24)
+		default:
+			f.done = true
+			return nil
+		}
+		if len(f.hdr) < size {
+			continue
+		}
+		maj, extra := f.hdr[0]>>5, uint64(f.hdr[0]&0x1f)
+		if size > 1 {
+			extra = 0
+			for _, c := range f.hdr[1:] {
+				extra = extra<<8 | uint64(c)
+			}
+		}
+		f.hdr = f.hdr[:0]
+		if err := f.consume(maj, extra); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consume processes a CBOR header with major type `maj` and argument `extra`.
+func (f *fieldReader) consume(maj byte, extra uint64) error {
+	if len(f.items) == 0 {
+		if maj != cbg.MajArray {
+			f.done = true
+			return nil
+		}
+		f.items = append(f.items, extra)
+	} else {
+		if len(f.items) == 1 {
+			if err := f.check(f.field, maj, extra); err != nil {
+				return err
+			}
+			f.field++
+		}
+		f.items[len(f.items)-1]--
+		switch maj {
+		case cbg.MajByteString, cbg.MajTextString:
+			f.skip = extra
+		case cbg.MajArray:
+			f.items = append(f.items, extra)
+		case cbg.MajUnsignedInt, cbg.MajNegativeInt, cbg.MajOther:
+		default:
+			f.done = true
+			return nil
+		}
+	}
+	for len(f.items) > 0 && f.items[len(f.items)-1] == 0 {
+		f.items = f.items[:len(f.items)-1]
+	}
+	f.done = len(f.items) == 0
+	return nil
+}
+
+// limitedReader reads at most `n` bytes from `r`, and records whether more are requested, so the
+// decoder stops as soon as an encoded model exceeds the size limit while streaming.
+type limitedReader struct {
+	r        io.Reader
+	n        uint64
+	exceeded bool
+}
+
+// newLimitedReader returns a limitedReader that reads at most `max` bytes from `r`,
+// or unlimited if `max` is 0.
+func newLimitedReader(r io.Reader, max uint64) *limitedReader {
+	if max == 0 {
+		max = math.MaxUint64
+	}
+	return &limitedReader{r: r, n: max}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if l.n == 0 {
+		l.exceeded = true
+		return 0, io.ErrUnexpectedEOF
+	}
+	if uint64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= uint64(n)
+	return n, err
+}
+
+// preallocTxs returns the capacity to preallocate for `count` transactions.
+func preallocTxs(count uint64) uint64 {
+	if count > maxPreallocTxs {
+		return maxPreallocTxs
+	}
+	return count
+}
@@ -0,0 +1,86 @@
+package model_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestLimits(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	bx := test.GenSignedBlock([]BlockHash{test.TestHash, test.TestHash2}, 3, 0, 3)
+	var buf bytes.Buffer
+	_, err := bx.WriteTo(&buf)
+	req.NoError(err)
+	bin := buf.Bytes()
+	txBin := bx.Txs[0].Bytes
+	var maxTxBytes, maxExtraBytes uint64
+	for _, txx := range bx.Txs {
+		if uint64(len(txx.Bytes)) > maxTxBytes {
+			maxTxBytes = uint64(len(txx.Bytes))
+		}
+		if uint64(len(txx.Extra)) > maxExtraBytes {
+			maxExtraBytes = uint64(len(txx.Extra))
+		}
+	}
+
+	read := func(limits Limits) error {
+		ut := New(test.Mrsh, test.Crpt)
+		ut.Limits = limits
+		_, _, err := ut.ReadBlockExtFrom(bytes.NewReader(bin))
+		return err
+	}
+
+	assr.NoError(read(Limits{}))
+	assr.NoError(read(Limits{
+		MaxTxCount:     3,
+		MaxTxBytes:     maxTxBytes,
+		MaxHeaderBytes: uint64(len(bx.Header.Bytes)),
+		MaxPrevHashes:  2,
+		MaxExtraBytes:  maxExtraBytes,
+	}))
+	assr.ErrorIs(read(Limits{MaxTxCount: 2}), ErrTooManyTxs)
+	assr.ErrorIs(read(Limits{MaxTxBytes: maxTxBytes - 1}), ErrTxTooLarge)
+	assr.ErrorIs(read(Limits{MaxHeaderBytes: uint64(len(bx.Header.Bytes)) - 1}), ErrHeaderTooLarge)
+	assr.ErrorIs(read(Limits{MaxPrevHashes: 1}), ErrTooManyPrevHashes)
+	assr.ErrorIs(read(Limits{MaxExtraBytes: maxExtraBytes - 1}), ErrExtraTooLarge)
+
+	ut := New(test.Mrsh, test.Crpt)
+	ut.Limits = Limits{MaxTxCount: 1000, MaxTxBytes: 16}
+	_, err = ut.TransactionExtFromBytes(txBin)
+	assr.ErrorIs(err, ErrTxTooLarge)
+
+	// Forged transaction count: 2^63 transactions in a block of a few bytes
+	txsBin := []byte{0x9b, 0x80, 0, 0, 0, 0, 0, 0, 0}
+	_, _, err = ut.ReadTransactionExtSliceFrom(bytes.NewReader(txsBin))
+	assr.ErrorIs(err, ErrTooManyTxs)
+	// It shouldn't allocate for the claimed count even without limits
+	_, _, err = test.Util.ReadTransactionExtSliceFrom(bytes.NewReader(txsBin))
+	assr.Error(err)
+
+	// Forged field lengths are rejected as soon as read without limiting the total size:
+	// a header with 2^32-1 PrevHashes and a transaction with 2^31-1 bytes of Extra
+	ut = New(test.Mrsh, test.Crpt)
+	ut.Limits = Limits{MaxPrevHashes: 2, MaxExtraBytes: maxExtraBytes}
+	_, _, err = ut.ReadBlockHeaderExtFrom(bytes.NewReader([]byte{0x89, 0x40, 0, 0x9a, 0xff, 0xff, 0xff, 0xff}))
+	assr.ErrorIs(err, ErrTooManyPrevHashes)
+	_, _, err = ut.ReadTransactionExtFrom(bytes.NewReader([]byte{0x87, 0, 0x40, 0, 0x40, 0x40, 0x5a, 0x7f, 0xff, 0xff, 0xff}))
+	assr.ErrorIs(err, ErrExtraTooLarge)
+	_, _, err = ut.ReadBlockExtFrom(bytes.NewReader(bin))
+	assr.NoError(err)
+
+	// Trailing bytes
+	_, err = test.Util.TransactionExtSliceFromBytesSlice([][]byte{append(append([]byte{}, txBin...), 0)})
+	assr.ErrorIs(err, ErrTrailingBytes)
+	_, err = test.Util.BlockExtFromBytes(append(append([]byte{}, bin...), 0))
+	assr.ErrorIs(err, ErrTrailingBytes)
+	_, err = test.Util.BlockExtFromBytes(bin)
+	assr.NoError(err)
+}
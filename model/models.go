@@ -4,6 +4,7 @@ import (
 	"io"
 	"unsafe"
 
+	"github.com/crpt/go-crpt"
 	cbg "github.com/daotl/cbor-gen"
 	"github.com/daotl/go-marsha"
 	"github.com/daotl/go-marsha/cborgen"
@@ -123,10 +124,16 @@ type TransactionExt struct {
 
 	// Pointer to the unmarshaled Transaction.Extra field
 	ExtraUnmarshaled ExtraPtr
+
+	// Whether Extra has been decoded by LoadExtra, guarded by extraMtx like ExtraUnmarshaled
+	extraDecoded bool
 }
 
 // Size calculates the estimated occupied memory of TransactionExt in bytes.
 func (txx *TransactionExt) Size() uint64 {
+	mtx := extraMtx(unsafe.Pointer(txx))
+	mtx.RLock()
+	defer mtx.RUnlock()
 	size := uint64(unsafe.Sizeof(txx)) +
 		txx.Transaction.Size() +
 		uint64(len(txx.Bytes)+len(txx.Hash))
@@ -223,18 +230,33 @@ type BlockHeaderExt struct {
 	// CBOR encoded BlockHeader
 	Bytes []byte
 
-	// Block hash (the same as BlockHeader hash)
+	// Block hash (the same as BlockHeader hash), which is either FullHash or NoSigHash
+	// according to Util.BlockHashMode
 	Hash BlockHash
 
+	// Hash of the CBOR encoded BlockHeader including signature, only set in BlockHashFull mode,
+	// see Util.FullHashOf
+	FullHash BlockHash
+
+	// Hash of the CBOR encoded BlockHeader without signature, only set in BlockHashNoSig mode,
+	// see Util.NoSigHashOf
+	NoSigHash BlockHash
+
 	// Pointer to the unmarshaled Transaction.Extra field
 	ExtraUnmarshaled ExtraPtr
+
+	// Whether Extra has been decoded by LoadExtra, guarded by extraMtx like ExtraUnmarshaled
+	extraDecoded bool
 }
 
 // Size calculates the estimated occupied memory of BlockHeaderExt in bytes.
 func (bhx *BlockHeaderExt) Size() uint64 {
+	mtx := extraMtx(unsafe.Pointer(bhx))
+	mtx.RLock()
+	defer mtx.RUnlock()
 	size := uint64(unsafe.Sizeof(bhx)) +
 		bhx.BlockHeader.Size() +
-		uint64(len(bhx.Bytes)+len(bhx.Hash))
+		uint64(len(bhx.Bytes)+len(bhx.Hash)+len(bhx.FullHash)+len(bhx.NoSigHash))
 	if bhx.ExtraUnmarshaled != nil {
 		size += bhx.ExtraUnmarshaled.Size()
 	}
@@ -286,6 +308,12 @@ func (bx *BlockExt) Raw() *Block {
 	return bx.block
 }
 
+// SetUtil sets the Util used to marshal the transactions in WriteTo and to extend the BlockExt in
+// UnmarshalJSON, for a BlockExt not created by a Util.
+func (bx *BlockExt) SetUtil(u *Util) {
+	bx.util = u
+}
+
 // Size calculates the estimated occupied memory of Block in bytes.
 func (bx *BlockExt) Size() uint64 {
 	size := uint64(unsafe.Sizeof(bx)) +
@@ -323,6 +351,131 @@ func (bx *BlockExt) WriteTo(w io.Writer) (n int, err error) {
 	return n, nil
 }
 
+// TransactionProof is a Merkle inclusion proof of a transaction in BlockHeader.TxRoot.
+type TransactionProof struct {
+
+	// Total number of transactions in the block
+	Total uint64 `json:"total"`
+
+	// Index of the transaction in the block
+	Index uint64 `json:"index"`
+
+	// Hashes from the leaf's sibling to a root's child
+	Aunts []Hash32 `json:"aunts,omitempty"`
+}
+
+// Ptr implements marsha.Struct
+func (p TransactionProof) Ptr() marsha.StructPtr { return &p }
+
+// Val implements marsha.StructPtr
+func (p *TransactionProof) Val() marsha.Struct { return *p }
+
+// TransactionMultiProof is a compact Merkle inclusion proof of multiple transactions in
+// BlockHeader.TxRoot, which doesn't repeat the hashes shared by the proofs of single transactions.
+type TransactionMultiProof struct {
+
+	// Total number of transactions in the block
+	Total uint64 `json:"total"`
+
+	// Indices of the transactions in the block in ascending order
+	Indices []uint64 `json:"indices"`
+
+	// Root hashes of the subtrees containing none of the transactions, ordered as they are consumed
+	// when computing the root hash bottom-up from left to right
+	Hashes []Hash32 `json:"hashes,omitempty"`
+}
+
+// Ptr implements marsha.Struct
+func (p TransactionMultiProof) Ptr() marsha.StructPtr { return &p }
+
+// Val implements marsha.StructPtr
+func (p *TransactionMultiProof) Val() marsha.Struct { return *p }
+
+// ToMultiProof converts the TransactionProof into an equivalent TransactionMultiProof.
+func (p *TransactionProof) ToMultiProof() *TransactionMultiProof {
+	return &TransactionMultiProof{
+		Total:   p.Total,
+		Indices: []uint64{p.Index},
+		Hashes:  p.Aunts,
+	}
+}
+
+// GenesisPayload is the payload in Transaction.Data of the genesis transaction which creates
+// a ledger, see Util.NewLedger.
+type GenesisPayload struct {
+	// Initial application state hash, which is used as the AppHash of the genesis block
+	AppHash []byte `json:"apphash,omitempty"`
+
+	// Initial ledger parameters (optional)
+	Params *LedgerParams `json:"params,omitempty"`
+
+	// Addresses of the initial block creators
+	// ([]Address is not supported by cbor-gen)
+	Creators [][]byte `json:"creators"`
+}
+
+// Ptr implements marsha.Struct
+func (p GenesisPayload) Ptr() marsha.StructPtr { return &p }
+
+// Val implements marsha.StructPtr
+func (p *GenesisPayload) Val() marsha.Struct { return *p }
+
+// LedgerParams defines the consensus-relevant parameters of a ledger, which are committed by the
+// genesis transaction and changed by the parameter change transactions.
+type LedgerParams struct {
+	// Version of the parameters, which must increase with every change
+	Version uint64 `json:"version"`
+
+	// Maximum size of an encoded block in bytes, 0 means no limit
+	MaxBlockBytes uint64 `json:"maxBlockBytes"`
+
+	// Maximum size of an encoded transaction in bytes, 0 means no limit
+	MaxTxBytes uint64 `json:"maxTxBytes"`
+
+	// Allowed TransactionTypes, empty means all types are allowed
+	AllowedTxTypes []byte `json:"allowedTxTypes,omitempty"`
+
+	// Key type of the signature algorithm
+	KeyType crpt.KeyType `json:"keyType"`
+
+	// Hash function as crypto.Hash (uint is not supported by cbor-gen)
+	HashFunc uint64 `json:"hashFunc"`
+}
+
+// Ptr implements marsha.Struct
+func (p LedgerParams) Ptr() marsha.StructPtr { return &p }
+
+// Val implements marsha.StructPtr
+func (p *LedgerParams) Val() marsha.Struct { return *p }
+
+// AllowsTxType reports whether transactions of type `t` are allowed by the parameters.
+func (p *LedgerParams) AllowsTxType(t TransactionType) bool {
+	if len(p.AllowedTxTypes) == 0 {
+		return true
+	}
+	for _, a := range p.AllowedTxTypes {
+		if TransactionType(a) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamChangePayload is the payload in Transaction.Data of the parameter change transactions.
+type ParamChangePayload struct {
+	// Height of the first block the new parameters take effect in
+	EffectiveHeight BlockHeight `json:"effectiveHeight"`
+
+	// New ledger parameters
+	Params *LedgerParams `json:"params"`
+}
+
+// Ptr implements marsha.Struct
+func (p ParamChangePayload) Ptr() marsha.StructPtr { return &p }
+
+// Val implements marsha.StructPtr
+func (p *ParamChangePayload) Val() marsha.Struct { return *p }
+
 // Extra is the interface the struct pointers to be put in TransactionExt.UnmarshaledExtra and
 // BlockHeaderExt.UnmarshaledExtra must implement.
 type ExtraPtr interface {
@@ -334,3 +487,7 @@ type ExtraPtr interface {
 
 // ExtraCtor is a function that creates a new instance of T, which is an ExtraPtr.
 type ExtraCtor[T ExtraPtr] func() T
+
+// PayloadCtor is a function that creates a new instance of T, which is the payload struct pointer
+// Transaction.Data is decoded into.
+type PayloadCtor[T marsha.StructPtr] func() T
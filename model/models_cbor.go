@@ -8,6 +8,7 @@ import (
 	"math"
 	"sort"
 
+	crpt "github.com/crpt/go-crpt"
 	cbg "github.com/daotl/cbor-gen"
 	cid "github.com/ipfs/go-cid"
 	xerrors "golang.org/x/xerrors"
@@ -837,3 +838,871 @@ func (t *Block) UnmarshalCBOR(r io.Reader) (int, error) {
 
 	return bytesRead, nil
 }
+
+func (t *TransactionProof) InitNilEmbeddedStruct() {
+	if t != nil {
+	}
+}
+
+var lengthBufTransactionProof = []byte{131}
+
+func (t *TransactionProof) MarshalCBOR(w io.Writer) (n int, err error) {
+	if t == nil {
+		return w.Write(cbg.CborNull)
+	}
+	t.InitNilEmbeddedStruct()
+	if n_, err := w.Write(lengthBufTransactionProof); err != nil {
+		return n_, err
+	} else {
+		n += n_
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Total (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Total)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Index (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Index)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Aunts ([][]uint8) (slice)
+	if len(t.Aunts) > cbg.MaxLength {
+		return n, xerrors.Errorf("Slice value in field t.Aunts was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Aunts))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+	for _, v := range t.Aunts {
+		if len(v) > cbg.ByteArrayMaxLen {
+			return n, xerrors.Errorf("Byte array in field v was too long")
+		}
+
+		if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(v))); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+
+		if n_, err := w.Write(v[:]); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+	}
+	return n, nil
+}
+
+func (t *TransactionProof) UnmarshalCBOR(r io.Reader) (int, error) {
+	bytesRead := 0
+	*t = TransactionProof{}
+	t.InitNilEmbeddedStruct()
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, read, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 3 {
+		return bytesRead, fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Total (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Total = uint64(extra)
+
+	}
+	// t.Index (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Index = uint64(extra)
+
+	}
+	// t.Aunts ([][]uint8) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.MaxLength {
+		return bytesRead, fmt.Errorf("t.Aunts: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Aunts = make([][]uint8, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+		{
+			var maj byte
+			var extra uint64
+			var err error
+
+			maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+			if err != nil {
+				return bytesRead, err
+			}
+			bytesRead += read
+
+			if extra > cbg.ByteArrayMaxLen {
+				return bytesRead, fmt.Errorf("t.Aunts[i]: byte array too large (%d)", extra)
+			}
+			if maj != cbg.MajByteString {
+				return bytesRead, fmt.Errorf("expected byte array")
+			}
+
+			if extra > 0 {
+				t.Aunts[i] = make([]uint8, extra)
+			}
+
+			if read, err := io.ReadFull(br, t.Aunts[i][:]); err != nil {
+				return bytesRead, err
+			} else {
+				bytesRead += read
+			}
+		}
+	}
+
+	return bytesRead, nil
+}
+
+func (t *TransactionMultiProof) InitNilEmbeddedStruct() {
+	if t != nil {
+	}
+}
+
+var lengthBufTransactionMultiProof = []byte{131}
+
+func (t *TransactionMultiProof) MarshalCBOR(w io.Writer) (n int, err error) {
+	if t == nil {
+		return w.Write(cbg.CborNull)
+	}
+	t.InitNilEmbeddedStruct()
+	if n_, err := w.Write(lengthBufTransactionMultiProof); err != nil {
+		return n_, err
+	} else {
+		n += n_
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Total (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Total)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Indices ([]uint64) (slice)
+	if len(t.Indices) > cbg.MaxLength {
+		return n, xerrors.Errorf("Slice value in field t.Indices was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Indices))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+	for _, v := range t.Indices {
+		if n_, err := cbg.CborWriteHeader(w, cbg.MajUnsignedInt, uint64(v)); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+	}
+
+	// t.Hashes ([][]uint8) (slice)
+	if len(t.Hashes) > cbg.MaxLength {
+		return n, xerrors.Errorf("Slice value in field t.Hashes was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Hashes))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+	for _, v := range t.Hashes {
+		if len(v) > cbg.ByteArrayMaxLen {
+			return n, xerrors.Errorf("Byte array in field v was too long")
+		}
+
+		if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(v))); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+
+		if n_, err := w.Write(v[:]); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+	}
+	return n, nil
+}
+
+func (t *TransactionMultiProof) UnmarshalCBOR(r io.Reader) (int, error) {
+	bytesRead := 0
+	*t = TransactionMultiProof{}
+	t.InitNilEmbeddedStruct()
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, read, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 3 {
+		return bytesRead, fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Total (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Total = uint64(extra)
+
+	}
+	// t.Indices ([]uint64) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.MaxLength {
+		return bytesRead, fmt.Errorf("t.Indices: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Indices = make([]uint64, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		maj, val, read, err := cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, xerrors.Errorf("failed to read uint64 for t.Indices slice: %w", err)
+		}
+		bytesRead += read
+
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, xerrors.Errorf("value read for array t.Indices was not a uint, instead got %d", maj)
+		}
+
+		t.Indices[i] = uint64(val)
+	}
+
+	// t.Hashes ([][]uint8) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.MaxLength {
+		return bytesRead, fmt.Errorf("t.Hashes: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Hashes = make([][]uint8, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+		{
+			var maj byte
+			var extra uint64
+			var err error
+
+			maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+			if err != nil {
+				return bytesRead, err
+			}
+			bytesRead += read
+
+			if extra > cbg.ByteArrayMaxLen {
+				return bytesRead, fmt.Errorf("t.Hashes[i]: byte array too large (%d)", extra)
+			}
+			if maj != cbg.MajByteString {
+				return bytesRead, fmt.Errorf("expected byte array")
+			}
+
+			if extra > 0 {
+				t.Hashes[i] = make([]uint8, extra)
+			}
+
+			if read, err := io.ReadFull(br, t.Hashes[i][:]); err != nil {
+				return bytesRead, err
+			} else {
+				bytesRead += read
+			}
+		}
+	}
+
+	return bytesRead, nil
+}
+
+func (t *GenesisPayload) InitNilEmbeddedStruct() {
+	if t != nil {
+	}
+}
+
+var lengthBufGenesisPayload = []byte{131}
+
+func (t *GenesisPayload) MarshalCBOR(w io.Writer) (n int, err error) {
+	if t == nil {
+		return w.Write(cbg.CborNull)
+	}
+	t.InitNilEmbeddedStruct()
+	if n_, err := w.Write(lengthBufGenesisPayload); err != nil {
+		return n_, err
+	} else {
+		n += n_
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.AppHash ([]uint8) (slice)
+	if len(t.AppHash) > cbg.ByteArrayMaxLen {
+		return n, xerrors.Errorf("Byte array in field t.AppHash was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.AppHash))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	if n_, err := w.Write(t.AppHash[:]); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Params (model.LedgerParams) (struct)
+	if n_, err := t.Params.MarshalCBOR(w); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Creators ([][]uint8) (slice)
+	if len(t.Creators) > cbg.MaxLength {
+		return n, xerrors.Errorf("Slice value in field t.Creators was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Creators))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+	for _, v := range t.Creators {
+		if len(v) > cbg.ByteArrayMaxLen {
+			return n, xerrors.Errorf("Byte array in field v was too long")
+		}
+
+		if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(v))); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+
+		if n_, err := w.Write(v[:]); err != nil {
+			return n + n_, err
+		} else {
+			n += n_
+		}
+	}
+	return n, nil
+}
+
+func (t *GenesisPayload) UnmarshalCBOR(r io.Reader) (int, error) {
+	bytesRead := 0
+	*t = GenesisPayload{}
+	t.InitNilEmbeddedStruct()
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, read, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 3 {
+		return bytesRead, fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.AppHash ([]uint8) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.ByteArrayMaxLen {
+		return bytesRead, fmt.Errorf("t.AppHash: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return bytesRead, fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.AppHash = make([]uint8, extra)
+	}
+
+	if read, err := io.ReadFull(br, t.AppHash[:]); err != nil {
+		return bytesRead, err
+	} else {
+		bytesRead += read
+	}
+	// t.Params (model.LedgerParams) (struct)
+
+	{
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead++
+		if b != cbg.CborNull[0] {
+			if err := br.UnreadByte(); err != nil {
+				return bytesRead, err
+			}
+			bytesRead--
+			t.Params = new(LedgerParams)
+			if read, err := t.Params.UnmarshalCBOR(br); err != nil {
+				return bytesRead, xerrors.Errorf("unmarshaling t.Params pointer: %w", err)
+			} else {
+				bytesRead += read
+			}
+		}
+
+	}
+	// t.Creators ([][]uint8) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.MaxLength {
+		return bytesRead, fmt.Errorf("t.Creators: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Creators = make([][]uint8, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+		{
+			var maj byte
+			var extra uint64
+			var err error
+
+			maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+			if err != nil {
+				return bytesRead, err
+			}
+			bytesRead += read
+
+			if extra > cbg.ByteArrayMaxLen {
+				return bytesRead, fmt.Errorf("t.Creators[i]: byte array too large (%d)", extra)
+			}
+			if maj != cbg.MajByteString {
+				return bytesRead, fmt.Errorf("expected byte array")
+			}
+
+			if extra > 0 {
+				t.Creators[i] = make([]uint8, extra)
+			}
+
+			if read, err := io.ReadFull(br, t.Creators[i][:]); err != nil {
+				return bytesRead, err
+			} else {
+				bytesRead += read
+			}
+		}
+	}
+
+	return bytesRead, nil
+}
+
+func (t *LedgerParams) InitNilEmbeddedStruct() {
+	if t != nil {
+	}
+}
+
+var lengthBufLedgerParams = []byte{134}
+
+func (t *LedgerParams) MarshalCBOR(w io.Writer) (n int, err error) {
+	if t == nil {
+		return w.Write(cbg.CborNull)
+	}
+	t.InitNilEmbeddedStruct()
+	if n_, err := w.Write(lengthBufLedgerParams); err != nil {
+		return n_, err
+	} else {
+		n += n_
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Version (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Version)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.MaxBlockBytes (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.MaxBlockBytes)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.MaxTxBytes (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.MaxTxBytes)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.AllowedTxTypes ([]uint8) (slice)
+	if len(t.AllowedTxTypes) > cbg.ByteArrayMaxLen {
+		return n, xerrors.Errorf("Byte array in field t.AllowedTxTypes was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.AllowedTxTypes))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	if n_, err := w.Write(t.AllowedTxTypes[:]); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.KeyType (crpt.KeyType) (uint8)
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.KeyType)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.HashFunc (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.HashFunc)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	return n, nil
+}
+
+func (t *LedgerParams) UnmarshalCBOR(r io.Reader) (int, error) {
+	bytesRead := 0
+	*t = LedgerParams{}
+	t.InitNilEmbeddedStruct()
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, read, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 6 {
+		return bytesRead, fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Version (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Version = uint64(extra)
+
+	}
+	// t.MaxBlockBytes (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.MaxBlockBytes = uint64(extra)
+
+	}
+	// t.MaxTxBytes (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.MaxTxBytes = uint64(extra)
+
+	}
+	// t.AllowedTxTypes ([]uint8) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.ByteArrayMaxLen {
+		return bytesRead, fmt.Errorf("t.AllowedTxTypes: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return bytesRead, fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.AllowedTxTypes = make([]uint8, extra)
+	}
+
+	if read, err := io.ReadFull(br, t.AllowedTxTypes[:]); err != nil {
+		return bytesRead, err
+	} else {
+		bytesRead += read
+	}
+	// t.KeyType (crpt.KeyType) (uint8)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajUnsignedInt {
+		return bytesRead, fmt.Errorf("wrong type for uint8 field")
+	}
+	if extra > math.MaxUint8 {
+		return bytesRead, fmt.Errorf("integer in input was too large for uint8 field")
+	}
+	t.KeyType = crpt.KeyType(extra)
+	// t.HashFunc (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.HashFunc = uint64(extra)
+
+	}
+	return bytesRead, nil
+}
+
+func (t *ParamChangePayload) InitNilEmbeddedStruct() {
+	if t != nil {
+	}
+}
+
+var lengthBufParamChangePayload = []byte{130}
+
+func (t *ParamChangePayload) MarshalCBOR(w io.Writer) (n int, err error) {
+	if t == nil {
+		return w.Write(cbg.CborNull)
+	}
+	t.InitNilEmbeddedStruct()
+	if n_, err := w.Write(lengthBufParamChangePayload); err != nil {
+		return n_, err
+	} else {
+		n += n_
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.EffectiveHeight (model.BlockHeight) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.EffectiveHeight)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Params (model.LedgerParams) (struct)
+	if n_, err := t.Params.MarshalCBOR(w); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+	return n, nil
+}
+
+func (t *ParamChangePayload) UnmarshalCBOR(r io.Reader) (int, error) {
+	bytesRead := 0
+	*t = ParamChangePayload{}
+	t.InitNilEmbeddedStruct()
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, read, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return bytesRead, fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.EffectiveHeight (model.BlockHeight) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.EffectiveHeight = BlockHeight(extra)
+
+	}
+	// t.Params (model.LedgerParams) (struct)
+
+	{
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead++
+		if b != cbg.CborNull[0] {
+			if err := br.UnreadByte(); err != nil {
+				return bytesRead, err
+			}
+			bytesRead--
+			t.Params = new(LedgerParams)
+			if read, err := t.Params.UnmarshalCBOR(br); err != nil {
+				return bytesRead, xerrors.Errorf("unmarshaling t.Params pointer: %w", err)
+			} else {
+				bytesRead += read
+			}
+		}
+
+	}
+	return bytesRead, nil
+}
@@ -220,3 +220,77 @@ func BenchmarkMarshalUnmarshal(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkExtendTransactionSlice(b *testing.B) {
+	txs := make(TransactionSlice, 10000)
+	for i := range txs {
+		txs[i] = *test.GenRandomTransaction()
+	}
+	ut := test.Util
+
+	b.Run("ExtendTransactionSlice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ut.ExtendTransactionSlice(txs)
+		}
+	})
+
+	b.Run("ExtendTransactionSliceParallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ut.ExtendTransactionSliceParallel(txs)
+		}
+	})
+
+	b.Run("HashTransactionSlice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ut.HashTransactionSlice(txs)
+		}
+	})
+
+	b.Run("HashTransactionSliceParallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ut.HashTransactionSliceParallel(txs)
+		}
+	})
+
+	b.Run("GenRootHashFromTransactionSlice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ut.GenRootHashFromTransactionSlice(txs)
+		}
+	})
+
+	b.Run("GenRootHashFromTransactionSliceParallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ut.GenRootHashFromTransactionSliceParallel(txs)
+		}
+	})
+}
+
+func BenchmarkVerifyTransactionSignature(b *testing.B) {
+	ut := test.Util
+	tx := &test.TestTransaction
+	txx, _ := ut.ExtendTransaction(tx)
+
+	b.Run("VerifyTransactionSignature", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ut.VerifyTransactionSignature(tx)
+		}
+	})
+
+	b.Run("VerifyTransactionExtSignature", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ut.VerifyTransactionExtSignature(txx)
+		}
+	})
+
+	b.Run("VerifyTransactionExtSignature with LedgerID", func(b *testing.B) {
+		ledgerUt := New(test.Mrsh, test.Crpt)
+		ledgerUt.LedgerID = test.TestHash
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ledgerUt.VerifyTransactionExtSignature(txx)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ExtendTransactionSliceParallel is the parallel variant of ExtendTransactionSlice, which marshals
+// and hashes the transactions using Util.Workers goroutines. The order of the output is the same as
+// ExtendTransactionSlice.
+//
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterTransactionExtra.
+func (u *Util) ExtendTransactionSliceParallel(txs TransactionSlice) (TransactionExtSlice, error) {
+	txxs := make(TransactionExtSlice, len(txs))
+	if err := u.parallelFor(len(txs), func(i int) (err error) {
+		txxs[i], err = u.ExtendTransaction(&txs[i])
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return txxs, nil
+}
+
+// HashTransactionSliceParallel is the parallel variant of HashTransactionSlice, which marshals and
+// hashes the transactions using Util.Workers goroutines. The order of the output is the same as
+// HashTransactionSlice.
+func (u *Util) HashTransactionSliceParallel(txs TransactionSlice) ([]TransactionHash, error) {
+	hs := make([]TransactionHash, len(txs))
+	if err := u.parallelFor(len(txs), func(i int) (err error) {
+		hs[i], err = u.HashTransaction(&txs[i])
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return hs, nil
+}
+
+// GenRootHashFromTransactionSliceParallel is the parallel variant of GenRootHashFromTransactionSlice,
+// which marshals and hashes the transactions using Util.Workers goroutines.
+func (u *Util) GenRootHashFromTransactionSliceParallel(txs TransactionSlice) (TransactionsRootHash, error) {
+	hs, err := u.HashTransactionSliceParallel(txs)
+	if err != nil {
+		return nil, err
+	}
+	return u.GenRootHashFromTransactionHashes(hs), nil
+}
+
+// workers returns the number of goroutines to use for parallel processing.
+func (u *Util) workers() int {
+	if u.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return u.Workers
+}
+
+// parallelFor calls `fn` for each index in [0, n) using at most Util.Workers goroutines.
+// If `fn` fails for some indices, it returns the error of the lowest index among them, so the
+// result is deterministic regardless of the scheduling.
+func (u *Util) parallelFor(n int, fn func(i int) error) error {
+	workers := u.workers()
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		next   int64 = -1
+		failed int64 = int64(n) // lowest failed index
+		err    error            // error of the lowest failed index
+		mtx    sync.Mutex
+		wg     sync.WaitGroup
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := atomic.AddInt64(&next, 1)
+				// Indices after a failed one don't affect the result
+				if i >= atomic.LoadInt64(&failed) {
+					return
+				}
+				if e := fn(int(i)); e != nil {
+					mtx.Lock()
+					if i < failed {
+						err = e
+						atomic.StoreInt64(&failed, i)
+					}
+					mtx.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TransactionTypeParamChange is the reserved TransactionType of the parameter change transactions,
+// its Transaction.Data is an encoded ParamChangePayload.
+const TransactionTypeParamChange TransactionType = math.MaxUint8 - 1
+
+var (
+	ErrInvalidParamChange   = errors.New("invalid parameter change")
+	ErrLedgerParamsMismatch = errors.New("ledger parameters mismatch")
+)
+
+// NewParamChangeTransaction creates an unsigned parameter change transaction from `from` with
+// `nonce`, which changes the ledger parameters to `params` from the block at `effectiveHeight` on.
+func (u *Util) NewParamChangeTransaction(from Address, nonce uint64, effectiveHeight BlockHeight,
+	params *LedgerParams,
+) (*Transaction, error) {
+	if params == nil {
+		return nil, fmt.Errorf("%w: no parameters", ErrInvalidParamChange)
+	}
+	return u.NewTransaction(TransactionTypeParamChange, from, nonce, nil, &ParamChangePayload{
+		EffectiveHeight: effectiveHeight,
+		Params:          params,
+	})
+}
+
+// DecodeParamChange decodes the ParamChangePayload of the parameter change transaction `tx`.
+// It fails with ErrInvalidParamChange if `tx` is not a valid parameter change transaction.
+func (u *Util) DecodeParamChange(tx *Transaction) (*ParamChangePayload, error) {
+	if tx.Type != TransactionTypeParamChange {
+		return nil, fmt.Errorf("%w: transaction type %d", ErrInvalidParamChange, tx.Type)
+	}
+	p, err := u.DecodeTransactionData(tx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParamChange, err)
+	}
+	payload := p.(*ParamChangePayload)
+	if payload.Params == nil {
+		return nil, fmt.Errorf("%w: no parameters", ErrInvalidParamChange)
+	}
+	return payload, nil
+}
+
+// EffectiveLedgerParams computes the ledger parameters effective at `height`, starting from the
+// parameters `genesis` committed by the genesis transaction and applying the parameter change
+// transactions `changes` in the order of ParamChangePayload.EffectiveHeight. The changes with the
+// same EffectiveHeight are applied in the order they appear in `changes`, which should be the
+// order they are committed in.
+//
+// It fails with ErrInvalidParamChange if any transaction in `changes` is not a valid parameter
+// change transaction, or LedgerParams.Version doesn't increase with every change. The returned
+// LedgerParams should not be modified since it may be shared with `genesis` or `changes`.
+//
+// NOTE: The authorization of the changes (e.g., the signatures and senders) is not checked.
+func (u *Util) EffectiveLedgerParams(genesis *LedgerParams, changes []*Transaction, height BlockHeight,
+) (*LedgerParams, error) {
+	if genesis == nil {
+		return nil, fmt.Errorf("%w: no genesis parameters", ErrInvalidParamChange)
+	}
+	payloads := make([]*ParamChangePayload, len(changes))
+	for i, tx := range changes {
+		p, err := u.DecodeParamChange(tx)
+		if err != nil {
+			return nil, fmt.Errorf("change %d: %w", i, err)
+		}
+		payloads[i] = p
+	}
+	sort.SliceStable(payloads, func(i, j int) bool {
+		return payloads[i].EffectiveHeight < payloads[j].EffectiveHeight
+	})
+
+	params := genesis
+	version := genesis.Version
+	for _, p := range payloads {
+		if p.Params.Version <= version {
+			return nil, fmt.Errorf("%w: version %d doesn't increase from %d at height %d",
+				ErrInvalidParamChange, p.Params.Version, version, p.EffectiveHeight)
+		}
+		version = p.Params.Version
+		if p.EffectiveHeight <= height {
+			params = p.Params
+		}
+	}
+	return params, nil
+}
+
+// GenesisLedgerParams returns the initial ledger parameters committed by the genesis transaction
+// `txx`, see VerifyGenesis.
+func (u *Util) GenesisLedgerParams(txx *TransactionExt) (*LedgerParams, error) {
+	payload, _, err := u.VerifyGenesis(txx)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Params == nil {
+		return nil, fmt.Errorf("%w: no ledger parameters", ErrInvalidGenesis)
+	}
+	return payload.Params, nil
+}
+
+// CheckLedgerParams checks that `u` uses the signature algorithm and hash function specified by
+// `params`, it fails with ErrLedgerParamsMismatch if not.
+func (u *Util) CheckLedgerParams(params *LedgerParams) error {
+	if params.KeyType != 0 && params.KeyType != u.Crpt.KeyType() {
+		return fmt.Errorf("%w: key type %v, Util uses %v", ErrLedgerParamsMismatch, params.KeyType,
+			u.Crpt.KeyType())
+	}
+	if params.HashFunc != 0 && params.HashFunc != uint64(u.Crpt.HashFunc()) {
+		return fmt.Errorf("%w: hash function %d, Util uses %d", ErrLedgerParamsMismatch,
+			params.HashFunc, u.Crpt.HashFunc())
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestLedgerParams(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+	ut := test.Util
+
+	genesis := &LedgerParams{
+		Version:        1,
+		MaxBlockBytes:  1 << 20,
+		MaxTxBytes:     1 << 16,
+		AllowedTxTypes: []byte{4, byte(TransactionTypeParamChange)},
+		KeyType:        test.Crpt.KeyType(),
+		HashFunc:       uint64(test.Crpt.HashFunc()),
+	}
+	req.NoError(ut.CheckLedgerParams(genesis))
+	assr.True(genesis.AllowsTxType(4))
+	assr.False(genesis.AllowsTxType(5))
+	assr.True((&LedgerParams{}).AllowsTxType(5))
+
+	txx, _, err := ut.NewLedger(test.TestPrivateKey, &GenesisPayload{
+		Creators: [][]byte{test.TestAddress},
+		Params:   genesis,
+	})
+	req.NoError(err)
+	genesis_, err := ut.GenesisLedgerParams(txx)
+	req.NoError(err)
+	assr.Equal(genesis, genesis_)
+
+	change := func(nonce uint64, height BlockHeight, version uint64) *Transaction {
+		params := *genesis
+		params.Version = version
+		params.MaxTxBytes = version << 16
+		tx, err := ut.NewParamChangeTransaction(test.TestAddress, nonce, height, &params)
+		req.NoError(err)
+		req.NoError(ut.SignTransaction(tx, test.TestPrivateKey))
+		return tx
+	}
+	// Committed out of order of the effective heights
+	changes := []*Transaction{change(1, 20, 3), change(2, 10, 2), change(3, 30, 4)}
+
+	for _, c := range []struct {
+		height  BlockHeight
+		version uint64
+	}{{0, 1}, {9, 1}, {10, 2}, {19, 2}, {20, 3}, {30, 4}, {1000, 4}} {
+		params, err := ut.EffectiveLedgerParams(genesis, changes, c.height)
+		req.NoError(err)
+		assr.Equal(c.version, params.Version, "height %d", c.height)
+		assr.Equal(c.version<<16, params.MaxTxBytes, "height %d", c.height)
+	}
+
+	// Version must increase
+	_, err = ut.EffectiveLedgerParams(genesis, append(changes, change(4, 40, 4)), 0)
+	assr.ErrorIs(err, ErrInvalidParamChange)
+	_, err = ut.EffectiveLedgerParams(genesis, []*Transaction{change(4, 5, 1)}, 0)
+	assr.ErrorIs(err, ErrInvalidParamChange)
+
+	// Not a parameter change transaction
+	_, err = ut.EffectiveLedgerParams(genesis, []*Transaction{&test.TestTransaction}, 0)
+	assr.ErrorIs(err, ErrInvalidParamChange)
+
+	// Mismatched algorithms
+	_, _, err = ut.NewLedger(test.TestPrivateKey, &GenesisPayload{
+		Creators: [][]byte{test.TestAddress},
+		Params:   &LedgerParams{HashFunc: 1},
+	})
+	assr.ErrorIs(err, ErrLedgerParamsMismatch)
+}
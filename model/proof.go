@@ -0,0 +1,225 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/crpt/go-merkle"
+)
+
+var (
+	ErrTxIndexOutOfRange = errors.New("transaction index out of range")
+	ErrInvalidProof      = errors.New("invalid transaction proof")
+)
+
+// ProveTransaction generates a Merkle inclusion proof of the transaction at `index` in the block
+// against BlockHeader.TxRoot.
+func (u *Util) ProveTransaction(bx *BlockExt, index int) (*TransactionProof, error) {
+	if index < 0 || index >= len(bx.Txs) {
+		return nil, fmt.Errorf("%w: %d", ErrTxIndexOutOfRange, index)
+	}
+	txhs := make([][]byte, len(bx.Txs))
+	for i, txx := range bx.Txs {
+		txhs[i] = txx.Hash
+	}
+	_, proofs := u.Crpt.MerkleProofsFromByteSlices(txhs)
+	p := proofs[index]
+	return &TransactionProof{
+		Total: uint64(p.Total),
+		Index: uint64(p.Index),
+		Aunts: p.Aunts,
+	}, nil
+}
+
+// VerifyTransactionProof verifies that the transaction with hash `txHash` is included in the
+// transactions with Merkle root hash `txRoot` by the proof. It returns ErrInvalidProof if not.
+func (u *Util) VerifyTransactionProof(txHash TransactionHash, proof *TransactionProof,
+	txRoot TransactionsRootHash,
+) error {
+	if proof.Total == 0 || proof.Total > math.MaxInt64 || proof.Index >= proof.Total {
+		return fmt.Errorf("%w: index %d, total %d", ErrInvalidProof, proof.Index, proof.Total)
+	}
+	if len(proof.Aunts) > merkle.MaxAunts {
+		return fmt.Errorf("%w: too many aunts (%d)", ErrInvalidProof, len(proof.Aunts))
+	}
+	p := &merkle.Proof{
+		Total:    int64(proof.Total),
+		Index:    int64(proof.Index),
+		LeafHash: u.merkleLeafHash(txHash),
+		Aunts:    proof.Aunts,
+		HashType: u.Crpt.HashFunc(),
+	}
+	if err := p.Verify(txRoot, txHash); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	return nil
+}
+
+// ProveTransactions generates a TransactionMultiProof of the transactions at `indices` in the block
+// against BlockHeader.TxRoot. Duplicate indices are ignored, and the indices in the proof are sorted
+// in ascending order.
+func (u *Util) ProveTransactions(bx *BlockExt, indices []int) (*TransactionMultiProof, error) {
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("%w: no index", ErrTxIndexOutOfRange)
+	}
+	idx := make([]uint64, 0, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= len(bx.Txs) {
+			return nil, fmt.Errorf("%w: %d", ErrTxIndexOutOfRange, i)
+		}
+		idx = append(idx, uint64(i))
+	}
+	sort.Slice(idx, func(i, j int) bool { return idx[i] < idx[j] })
+	uniq := idx[:1]
+	for _, i := range idx[1:] {
+		if i != uniq[len(uniq)-1] {
+			uniq = append(uniq, i)
+		}
+	}
+
+	txhs := make([][]byte, len(bx.Txs))
+	for i, txx := range bx.Txs {
+		txhs[i] = txx.Hash
+	}
+	p := &TransactionMultiProof{
+		Total:   uint64(len(txhs)),
+		Indices: uniq,
+	}
+	u.collectMultiProofHashes(txhs, 0, uniq, &p.Hashes)
+	return p, nil
+}
+
+// collectMultiProofHashes appends to `hashes` the root hashes of the subtrees of the tree of `txhs`
+// containing none of `indices`, `offset` is the index of txhs[0] in the whole tree.
+func (u *Util) collectMultiProofHashes(txhs [][]byte, offset uint64, indices []uint64, hashes *[]Hash32) {
+	if len(txhs) == 1 {
+		return
+	}
+	k := merkleSplitPoint(uint64(len(txhs)))
+	i := sort.Search(len(indices), func(i int) bool { return indices[i] >= offset+k })
+	left, right := indices[:i], indices[i:]
+	if len(left) > 0 {
+		u.collectMultiProofHashes(txhs[:k], offset, left, hashes)
+	}
+	if len(right) > 0 {
+		u.collectMultiProofHashes(txhs[k:], offset+k, right, hashes)
+	}
+	if len(left) == 0 {
+		*hashes = append(*hashes, u.Crpt.MerkleHashFromByteSlices(txhs[:k]))
+	}
+	if len(right) == 0 {
+		*hashes = append(*hashes, u.Crpt.MerkleHashFromByteSlices(txhs[k:]))
+	}
+}
+
+// VerifyTransactionMultiProof verifies that the transactions with hashes `txHashes` are included
+// in the transactions with Merkle root hash `txRoot` by the proof, `txHashes[i]` should be the hash
+// of the transaction at `proof.Indices[i]`. It returns ErrInvalidProof if not.
+//
+// A TransactionProof can also be verified by this after being converted by
+// TransactionProof.ToMultiProof.
+func (u *Util) VerifyTransactionMultiProof(txHashes []TransactionHash, proof *TransactionMultiProof,
+	txRoot TransactionsRootHash,
+) error {
+	if proof.Total == 0 || len(proof.Indices) == 0 {
+		return fmt.Errorf("%w: empty proof", ErrInvalidProof)
+	}
+	if len(txHashes) != len(proof.Indices) {
+		return fmt.Errorf("%w: %d transaction hashes for %d indices",
+			ErrInvalidProof, len(txHashes), len(proof.Indices))
+	}
+	for i, idx := range proof.Indices {
+		if idx >= proof.Total || (i > 0 && idx <= proof.Indices[i-1]) {
+			return fmt.Errorf("%w: indices should be ascending and less than total", ErrInvalidProof)
+		}
+	}
+
+	v := &multiProofVerifier{u: u, txHashes: txHashes, hashes: proof.Hashes}
+	root, ok := v.compute(0, proof.Total, proof.Indices)
+	if !ok || len(v.hashes) != 0 || !bytes.Equal(root, txRoot) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// multiProofVerifier computes the root hash from a TransactionMultiProof, consuming the hashes in
+// the same order as collectMultiProofHashes appends them.
+type multiProofVerifier struct {
+	u        *Util
+	txHashes []TransactionHash
+	hashes   []Hash32
+
+	// Index of the next transaction hash to consume
+	next int
+}
+
+// compute returns the root hash of the subtree of size `total` starting at `offset`, which contains
+// the transactions at `indices`.
+func (v *multiProofVerifier) compute(offset, total uint64, indices []uint64) ([]byte, bool) {
+	if total == 1 {
+		// The leaves are visited in ascending order, so indices[0] == offset is the next one
+		h := v.u.merkleLeafHash(v.txHashes[v.next])
+		v.next++
+		return h, true
+	}
+	k := merkleSplitPoint(total)
+	i := sort.Search(len(indices), func(i int) bool { return indices[i] >= offset+k })
+	left, right := indices[:i], indices[i:]
+	var l, r []byte
+	ok := true
+	if len(left) > 0 {
+		l, ok = v.compute(offset, k, left)
+	}
+	if ok && len(right) > 0 {
+		r, ok = v.compute(offset+k, total-k, right)
+	}
+	if ok && len(left) == 0 {
+		l, ok = v.consumeHash()
+	}
+	if ok && len(right) == 0 {
+		r, ok = v.consumeHash()
+	}
+	if !ok {
+		return nil, false
+	}
+	return v.u.merkleInnerHash(l, r), true
+}
+
+func (v *multiProofVerifier) consumeHash() ([]byte, bool) {
+	if len(v.hashes) == 0 {
+		return nil, false
+	}
+	h := v.hashes[0]
+	v.hashes = v.hashes[1:]
+	return h, true
+}
+
+// merkleSplitPoint returns the largest power of 2 less than `length`, which is the size of the left
+// subtree of a RFC-6962 Merkle tree with `length` leaves.
+func merkleSplitPoint(length uint64) uint64 {
+	k := uint64(1) << (bits.Len64(length) - 1)
+	if k == length {
+		k >>= 1
+	}
+	return k
+}
+
+// merkleInnerHash returns the RFC-6962 Merkle tree inner node hash: hash(0x01 || left || right),
+// which is not exported by go-merkle.
+func (u *Util) merkleInnerHash(left, right []byte) []byte {
+	h := u.Crpt.HashFunc().New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleLeafHash returns the RFC-6962 Merkle tree leaf hash of `leaf`, which is the root hash of
+// the Merkle tree with only `leaf`.
+func (u *Util) merkleLeafHash(leaf []byte) []byte {
+	return u.Crpt.MerkleHashFromByteSlices([][]byte{leaf})
+}
@@ -0,0 +1,112 @@
+package model_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestTransactionProof(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+	ut := test.Util
+
+	for _, txCount := range []int{1, 2, 5, 8, 13} {
+		bx := test.GenSignedBlock(nil, 1, 0, txCount)
+		root := bx.Header.TxRoot
+		for i, txx := range bx.Txs {
+			p, err := ut.ProveTransaction(bx, i)
+			req.NoError(err)
+			assr.NoError(ut.VerifyTransactionProof(txx.Hash, p, root))
+
+			// Should survive CBOR round trip
+			bin, err := ut.Mrsh.MarshalStruct(p)
+			req.NoError(err)
+			p_ := &TransactionProof{}
+			_, err = ut.Mrsh.UnmarshalStruct(bin, p_)
+			req.NoError(err)
+			assr.NoError(ut.VerifyTransactionProof(txx.Hash, p_, root))
+
+			// Should not prove other transactions
+			other := bx.Txs[(i+1)%len(bx.Txs)].Hash
+			if !bytes.Equal(other, txx.Hash) {
+				assr.ErrorIs(ut.VerifyTransactionProof(other, p, root), ErrInvalidProof)
+			}
+			assr.ErrorIs(ut.VerifyTransactionProof(txx.Hash, p, test.TestHash), ErrInvalidProof)
+		}
+	}
+
+	bx := test.GenSignedBlock(nil, 1, 0, 3)
+	_, err := ut.ProveTransaction(bx, 3)
+	assr.ErrorIs(err, ErrTxIndexOutOfRange)
+	assr.ErrorIs(ut.VerifyTransactionProof(bx.Txs[0].Hash, &TransactionProof{}, bx.Header.TxRoot),
+		ErrInvalidProof)
+}
+
+func TestTransactionMultiProof(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+	ut := test.Util
+
+	bx := test.GenSignedBlock(nil, 1, 0, 13)
+	root := bx.Header.TxRoot
+	hashesAt := func(indices []uint64) []TransactionHash {
+		hs := make([]TransactionHash, len(indices))
+		for i, idx := range indices {
+			hs[i] = bx.Txs[idx].Hash
+		}
+		return hs
+	}
+
+	for _, indices := range [][]int{{0}, {12}, {3, 4}, {0, 12}, {7, 1, 5, 1}, {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}} {
+		p, err := ut.ProveTransactions(bx, indices)
+		req.NoError(err)
+		assr.NoError(ut.VerifyTransactionMultiProof(hashesAt(p.Indices), p, root))
+
+		bin, err := ut.Mrsh.MarshalStruct(p)
+		req.NoError(err)
+		p_ := &TransactionMultiProof{}
+		_, err = ut.Mrsh.UnmarshalStruct(bin, p_)
+		req.NoError(err)
+		assr.Equal(p, p_)
+
+		// Swapped transaction hashes should fail
+		if len(p.Indices) > 1 {
+			hs := hashesAt(p.Indices)
+			hs[0], hs[1] = hs[1], hs[0]
+			assr.ErrorIs(ut.VerifyTransactionMultiProof(hs, p, root), ErrInvalidProof)
+		}
+		assr.ErrorIs(ut.VerifyTransactionMultiProof(hashesAt(p.Indices), p, test.TestHash), ErrInvalidProof)
+	}
+
+	// Multiproof of 2 transactions should be smaller than 2 single proofs
+	p, err := ut.ProveTransactions(bx, []int{2, 3})
+	req.NoError(err)
+	p1, err := ut.ProveTransaction(bx, 2)
+	req.NoError(err)
+	p2, err := ut.ProveTransaction(bx, 3)
+	req.NoError(err)
+	assr.Less(len(p.Hashes), len(p1.Aunts)+len(p2.Aunts))
+
+	// Interoperable with single transaction proofs
+	for i := range bx.Txs {
+		p, err := ut.ProveTransaction(bx, i)
+		req.NoError(err)
+		mp, err := ut.ProveTransactions(bx, []int{i})
+		req.NoError(err)
+		assr.Equal(mp, p.ToMultiProof())
+		assr.NoError(ut.VerifyTransactionMultiProof(hashesAt(mp.Indices), p.ToMultiProof(), root))
+	}
+
+	_, err = ut.ProveTransactions(bx, []int{13})
+	assr.ErrorIs(err, ErrTxIndexOutOfRange)
+	p, err = ut.ProveTransactions(bx, []int{1, 2})
+	req.NoError(err)
+	p.Hashes = append(p.Hashes, test.TestHash)
+	assr.ErrorIs(ut.VerifyTransactionMultiProof(hashesAt(p.Indices), p, root), ErrInvalidProof)
+}
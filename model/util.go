@@ -3,8 +3,9 @@ package model
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"math"
 	"sync"
 	"unsafe"
 
@@ -14,22 +15,114 @@ import (
 )
 
 var (
-	ErrInvalidBytes = errors.New("failed to unmarshal because of invalid bytes")
+	ErrInvalidBytes       = errors.New("failed to unmarshal because of invalid bytes")
+	ErrInvalidSignature   = errors.New("invalid signature")
+	ErrPrevHashesMismatch = errors.New("previous block hashes mismatch")
 )
 
-const maxCBORHeaderSize = 9
+const (
+	maxCBORHeaderSize = 9
+
+	// Buffers larger than this are not put back into Util.msgBufPool to avoid holding too much memory.
+	maxPooledMsgBufSize = 1 << 16
+)
+
+// SignatureDomainVersion is the version tag of the domain-separated signing scheme.
+//
+// When Util.LedgerID is set, the message signed for a model is:
+//
+//	domain tag || SignatureDomainVersion || LedgerID || CBOR-encoded model without signature
+//
+// so a signature made for one ledger will be rejected by any other ledger using the same Crpt.
+//
+// Genesis transactions (of TransactionTypeGenesis) are never bound to a ledger, since the LedgerID
+// is derived from them. To prevent them from being replayed on other ledgers, their signatures are
+// only verified by VerifyGenesis once Util.LedgerID is set, see checkSignedType.
+const SignatureDomainVersion = byte(1)
+
+// Domain tags of Transaction and BlockHeader signatures.
+var (
+	transactionSignatureDomain = []byte("DOUBL/Transaction")
+	blockHeaderSignatureDomain = []byte("DOUBL/BlockHeader")
+)
+
+// transactionSignDomain returns the signature domain of the transactions of type `t`,
+// nil means the signatures are not bound to a ledger.
+func transactionSignDomain(t TransactionType) []byte {
+	if t == TransactionTypeGenesis {
+		return nil
+	}
+	return transactionSignatureDomain
+}
+
+// checkSignedType rejects the transactions of type `t` whose signatures are not bound to the ledger
+// when Util.LedgerID is set, i.e., genesis transactions, which are only verified by VerifyGenesis.
+func (u *Util) checkSignedType(t TransactionType) error {
+	if t == TransactionTypeGenesis && u.LedgerID != nil {
+		return fmt.Errorf("%w: genesis transaction signature is only verified by VerifyGenesis",
+			ErrInvalidGenesis)
+	}
+	return nil
+}
+
+// BlockHashMode specifies which bytes of the BlockHeader the block hash (the identity of the block)
+// is computed over.
+type BlockHashMode uint8
+
+const (
+	// BlockHashFull computes the block hash over the full CBOR-encoded BlockHeader
+	// including the creator signature.
+	BlockHashFull BlockHashMode = iota
+
+	// BlockHashNoSig computes the block hash over the CBOR-encoded BlockHeader without the creator
+	// signature, so the identity of a block doesn't change if the signature is re-encoded,
+	// and child blocks can reference a block before it's signed.
+	BlockHashNoSig
+)
 
 // Util provides utility methods to work with DOUBL models.
 type Util struct {
 	Mrsh marsha.Marsha
 	Crpt crpt.Crpt
 
+	// LedgerID binds the signatures signed and verified by Util to the ledger if set.
+	// If nil, the CBOR-encoded models without signature are signed directly.
+	LedgerID LedgerID
+
+	// BlockHashMode specifies how BlockHeaderExt.Hash is computed, defaults to BlockHashFull.
+	BlockHashMode BlockHashMode
+
+	// Workers is the number of goroutines used by the parallel variants of the methods (the ones
+	// with "Parallel" suffix), defaults to runtime.NumCPU() if <= 0.
+	Workers int
+
+	// LazyExtra disables decoding Extra fields when extending or reading models, they can be decoded
+	// on demand by TransactionExt.LoadExtra and BlockHeaderExt.LoadExtra instead.
+	LazyExtra bool
+
+	// StrictDecoding makes reading encoded models fail with ErrNonCanonicalEncoding if they are not
+	// in the canonical form, which is the form Util.Mrsh marshals them into. Otherwise, different
+	// encodings of the same model that Util.Mrsh accepts would result in different hashes.
+	StrictDecoding bool
+
+	// Limits specifies the limits enforced when reading encoded models, no limit by default.
+	Limits Limits
+
 	cborHeaderBufPool sync.Pool
+
+	// Pool of buffers for building messages to verify signatures against
+	msgBufPool sync.Pool
+
+	// ExtraCtors registered for Transaction.Extra of each TransactionType and BlockHeader.Extra
+	txExtraCtors      [math.MaxUint8 + 1]ExtraCtor[ExtraPtr]
+	txExtraRegistered bool
+	bhExtraCtor       ExtraCtor[ExtraPtr]
+	txPayloadCtors    [math.MaxUint8 + 1]PayloadCtor[marsha.StructPtr]
 }
 
 // New creates a new Util with the specified Marsha and Crpt instances.
 func New(mrsh marsha.Marsha, crpt crpt.Crpt) *Util {
-	return &Util{
+	u := &Util{
 		Mrsh: mrsh,
 		Crpt: crpt,
 		cborHeaderBufPool: sync.Pool{
@@ -38,7 +131,18 @@ func New(mrsh marsha.Marsha, crpt crpt.Crpt) *Util {
 				return &b
 			},
 		},
+		msgBufPool: sync.Pool{
+			New: func() interface{} {
+				b := make([]byte, 0, 512)
+				return &b
+			},
+		},
 	}
+	u.RegisterTransactionData(TransactionTypeGenesis,
+		AsPayloadCtor(func() *GenesisPayload { return new(GenesisPayload) }))
+	u.RegisterTransactionData(TransactionTypeParamChange,
+		AsPayloadCtor(func() *ParamChangePayload { return new(ParamChangePayload) }))
+	return u
 }
 
 // TransactionsFromExtPtrs returns the Transactions wrapped in the TransactionExtSlice.
@@ -53,8 +157,8 @@ func TransactionSliceFromExtSlice(txxs TransactionExtSlice) TransactionSlice {
 // BlockHeadersFromExts returns the BlockHeaders wrapped in the BlockHeaderExts.
 func BlockHeadersFromExts(bhxs []BlockHeaderExt) []BlockHeader {
 	bhs := make([]BlockHeader, len(bhxs))
-	for i, bhx := range bhxs {
-		bhs[i] = *bhx.BlockHeader
+	for i := range bhxs {
+		bhs[i] = *bhxs[i].BlockHeader
 	}
 	return bhs
 }
@@ -70,22 +174,29 @@ func BlocksFromExts(bxs []BlockExt) []Block {
 
 // ExtendTransaction extends a Transaction into a TransactionExt.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterTransactionExtra.
 func (u *Util) ExtendTransaction(tx *Transaction) (*TransactionExt, error) {
 	bin, err := u.Mrsh.MarshalStruct(tx)
 	if err != nil {
 		return nil, err
 	}
+	extra, err := u.eagerTransactionExtra(tx)
+	if err != nil {
+		return nil, err
+	}
 	return &TransactionExt{
-		Transaction: tx,
-		Bytes:       bin,
-		Hash:        u.Crpt.Hash(bin),
+		Transaction:      tx,
+		Bytes:            bin,
+		Hash:             u.Crpt.Hash(bin),
+		ExtraUnmarshaled: extra,
 	}, nil
 }
 
 // ExtendTransaction extends TransactionSlice into TransactionExtSlice.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterTransactionExtra.
 func (u *Util) ExtendTransactionSlice(txs TransactionSlice) (TransactionExtSlice, error) {
 	txxs := make(TransactionExtSlice, len(txs))
 	var err error
@@ -100,20 +211,36 @@ func (u *Util) ExtendTransactionSlice(txs TransactionSlice) (TransactionExtSlice
 // ReadTransactionExtFrom reads and unmarshals the encoded transaction from `r`
 // and extends it into a TransactionExt, it also returns the number of bytes read.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterTransactionExtra.
 func (u *Util) ReadTransactionExtFrom(r io.Reader) (txx *TransactionExt, n int64, err error) {
 	txx = &TransactionExt{
 		Transaction: new(Transaction),
 	}
 	var buf bytes.Buffer
-	tr := io.TeeReader(r, &buf)
+	lr := newLimitedReader(r, u.Limits.MaxTxBytes)
+	fr := u.Limits.newTransactionFieldReader(lr)
+	tr := io.TeeReader(fr, &buf)
 	n_, err := u.Mrsh.NewDecoder(tr).DecodeStruct(txx.Transaction)
 	n = int64(n_)
-	if err != nil {
+	if lr.exceeded {
+		return nil, n, fmt.Errorf("%w: more than %d bytes", ErrTxTooLarge, u.Limits.MaxTxBytes)
+	} else if fr.err != nil {
+		return nil, n, fr.err
+	} else if err != nil {
 		return nil, n, err
 	}
 	txx.Bytes = buf.Bytes()
+	if err = u.Limits.checkTransaction(txx.Transaction, uint64(len(txx.Bytes))); err != nil {
+		return nil, n, err
+	}
+	if err = u.checkCanonical(txx.Transaction, txx.Bytes); err != nil {
+		return nil, n, err
+	}
 	txx.Hash = u.Crpt.Hash(txx.Bytes)
+	if txx.ExtraUnmarshaled, err = u.eagerTransactionExtra(txx.Transaction); err != nil {
+		return nil, n, err
+	}
 	return txx, n, err
 }
 
@@ -121,7 +248,8 @@ func (u *Util) ReadTransactionExtFrom(r io.Reader) (txx *TransactionExt, n int64
 // TransactionExt.Bytes points to the same underlying memory as `bin` for performance consideration,
 // it's not safe to modify it anywhere.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterTransactionExtra.
 //
 // Deprecated: Use ReadTransactionExtFrom instead.
 func (u *Util) TransactionExtFromBytes(bin []byte) (*TransactionExt, error) {
@@ -133,7 +261,16 @@ func (u *Util) TransactionExtFromBytes(bin []byte) (*TransactionExt, error) {
 		return nil, err
 	}
 	txx.Bytes = bin[:read]
+	if err = u.Limits.checkTransaction(txx.Transaction, uint64(read)); err != nil {
+		return nil, err
+	}
+	if err = u.checkCanonical(txx.Transaction, txx.Bytes); err != nil {
+		return nil, err
+	}
 	txx.Hash = u.Crpt.Hash(bin[:read])
+	if txx.ExtraUnmarshaled, err = u.eagerTransactionExtra(txx.Transaction); err != nil {
+		return nil, err
+	}
 	return txx, err
 }
 
@@ -141,13 +278,17 @@ func (u *Util) TransactionExtFromBytes(bin []byte) (*TransactionExt, error) {
 // TransactionExt.Bytes points to the same underlying memory as `bins` for performance consideration,
 // it's not safe to modify it anywhere.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterTransactionExtra.
 func (u *Util) TransactionExtSliceFromBytesSlice(bins [][]byte) (TransactionExtSlice, error) {
 	txxs := make(TransactionExtSlice, len(bins))
 	var err error
+	var n int64
 	for i, bin := range bins {
-		if txxs[i], _, err = u.ReadTransactionExtFrom(bytes.NewReader(bin)); err != nil {
+		if txxs[i], n, err = u.ReadTransactionExtFrom(bytes.NewReader(bin)); err != nil {
 			return nil, err
+		} else if n != int64(len(bin)) {
+			return nil, fmt.Errorf("%w: transaction %d", ErrTrailingBytes, i)
 		}
 	}
 	return txxs, nil
@@ -168,9 +309,11 @@ func (u *Util) ReadTransactionExtSliceFrom(r io.Reader) (
 		return nil, n, nil
 	} else if majorType != cbg.MajArray {
 		return nil, n, ErrInvalidBytes
+	} else if err = u.Limits.checkTxCount(extra); err != nil {
+		return nil, n, err
 	}
 
-	txxs = make(TransactionExtSlice, 0, extra)
+	txxs = make(TransactionExtSlice, 0, preallocTxs(extra))
 	for i := uint64(0); i < extra; i++ {
 		txx, n_, err := u.ReadTransactionExtFrom(r)
 		n += n_
@@ -198,9 +341,11 @@ func (u *Util) TransactionExtSliceFromTransactionsBytes(bin []byte) (Transaction
 		return nil, err
 	} else if majorType != cbg.MajArray {
 		return nil, ErrInvalidBytes
+	} else if err = u.Limits.checkTxCount(count); err != nil {
+		return nil, err
 	}
 
-	txxs := make(TransactionExtSlice, 0, count)
+	txxs := make(TransactionExtSlice, 0, preallocTxs(count))
 	for i := uint64(0); i < count; i++ {
 		txx, err := u.TransactionExtFromBytes(bin[offset:])
 		if err != nil {
@@ -209,6 +354,9 @@ func (u *Util) TransactionExtSliceFromTransactionsBytes(bin []byte) (Transaction
 		txxs = append(txxs, txx)
 		offset += len(txx.Bytes)
 	}
+	if offset != len(bin) {
+		return txxs, fmt.Errorf("%w: %d bytes", ErrTrailingBytes, len(bin)-offset)
+	}
 	return txxs, nil
 }
 
@@ -260,50 +408,267 @@ func (u *Util) HashTransactionSlice(txs TransactionSlice) (hs []TransactionHash,
 	return hs, nil
 }
 
+// SignTransaction signs the transaction with `priv` and sets Transaction.Sig.
+// The signature is bound to Util.LedgerID if set.
+func (u *Util) SignTransaction(tx *Transaction, priv crpt.PrivateKey) error {
+	_, err := u.signTransaction(tx, priv)
+	return err
+}
+
+// SignTransactionExt signs the transaction with `priv`, sets Transaction.Sig and refreshes
+// TransactionExt.Bytes and TransactionExt.Hash by appending the signature to the transaction bytes.
+// The signature is bound to Util.LedgerID if set.
+func (u *Util) SignTransactionExt(txx *TransactionExt, priv crpt.PrivateKey) error {
+	bin, err := u.signTransaction(txx.Transaction, priv)
+	if err != nil {
+		return err
+	}
+	txx.Bytes = appendSignature(bin, txx.Sig)
+	txx.Hash = u.Crpt.Hash(txx.Bytes)
+	return nil
+}
+
+// signTransaction signs the transaction and sets Transaction.Sig,
+// it also returns the CBOR-encoded transaction without signature.
+func (u *Util) signTransaction(tx *Transaction, priv crpt.PrivateKey) (noSig []byte, err error) {
+	if noSig, err = u.Mrsh.MarshalStruct(getTxNoSig(tx)); err != nil {
+		return nil, err
+	}
+	sig, err := priv.SignMessage(u.signMessage(transactionSignDomain(tx.Type), noSig), nil)
+	if err != nil {
+		return nil, err
+	}
+	tx.Sig = sig
+	return noSig, nil
+}
+
+// VerifyTransaction verifies the transaction signature like VerifyTransactionSignature,
+// but returns ErrInvalidSignature if the signature is invalid.
+func (u *Util) VerifyTransaction(tx *Transaction) error {
+	ok, err := u.VerifyTransactionSignature(tx)
+	if err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
 // VerifyTransactionSignature verifies the transaction signature.
 // Should prefer using VerifyTransactionExtSignature instead for better performance.
-// TODO: prepend genesis block hash to bin
+//
+// Genesis transactions are rejected with ErrInvalidGenesis if Util.LedgerID is set, use
+// VerifyGenesis instead.
 func (u *Util) VerifyTransactionSignature(tx *Transaction) (bool, error) {
+	if err := u.checkSignedType(tx.Type); err != nil {
+		return false, err
+	}
 	sig := tx.Sig
-	//fmt.Printf("sig: %0x\n", sig)
 	txNoSig := getTxNoSig(tx)
 	bin, err := u.Mrsh.MarshalStruct(txNoSig)
 	if err != nil {
 		return false, err
 	}
-	//fmt.Printf("bin: %0x\n", bin)
 
 	pub, err := u.Crpt.PublicKeyFromBytes(tx.From)
 	if err != nil {
 		return false, err
 	}
-	//fmt.Printf("pub: %0x\n", pub)
-	return pub.VerifyMessage(bin, sig)
+	return pub.VerifyMessage(u.signMessage(transactionSignDomain(tx.Type), bin), sig)
 }
 
 // 0x41=64
 const signatureCborDataLengthByte = byte(SignatureCborDataLength)
 
 // VerifyTransactionExtSignature verifies the transaction signature from TransactionExt.
-// TODO: Prepend genesis block hash to bin
+//
+// Genesis transactions are rejected with ErrInvalidGenesis if Util.LedgerID is set, use
+// VerifyGenesis instead.
 func (u *Util) VerifyTransactionExtSignature(txx *TransactionExt) (bool, error) {
-	// In CBOR-encoded Transaction bytes:
-	// if the signature is set, it's encoded as a byte string with txNoSigLen 64;
-	// if not, it's a byte string with txNoSigLen 0, not `null`
-	txNoSigLen := len(txx.Bytes) - SignatureCborDataLength - 1
-	txNoSigBytes := make([]byte, txNoSigLen)
-	copy(txNoSigBytes, txx.Bytes[:txNoSigLen-1])
-	txNoSigBytes[txNoSigLen-1] = signatureCborDataLengthByte
-
-	pub, err := u.Crpt.PublicKeyFromBytes(txx.From)
+	if err := u.checkSignedType(txx.Type); err != nil {
+		return false, err
+	}
+	return u.verifyExtSignature(transactionSignDomain(txx.Type), txx.Bytes, txx.Sig, txx.From)
+}
+
+// verifyExtSignature verifies `sig` of the CBOR-encoded model with signature `bin` in the signature
+// domain `domain` by the public key `pub`, without re-marshaling the model. The message is built in
+// a pooled buffer, so no copy of `bin` is allocated.
+func (u *Util) verifyExtSignature(domain []byte, bin []byte, sig Signature, pub []byte) (bool, error) {
+	if _, ok := noSigLen(bin, sig); !ok {
+		return false, nil
+	}
+	pk, err := u.Crpt.PublicKeyFromBytes(pub)
+	if err != nil {
+		return false, err
+	}
+
+	bufp := u.msgBufPool.Get().(*[]byte)
+	msg := u.appendSignDomain((*bufp)[:0], domain)
+	msg, _ = appendNoSigBytes(msg, bin, sig)
+	ok, err := pk.VerifyMessage(msg, sig)
+	if cap(msg) <= maxPooledMsgBufSize {
+		*bufp = msg
+		u.msgBufPool.Put(bufp)
+	}
+	return ok, err
+}
+
+// extSignMessage returns the message to be signed for the CBOR-encoded model with signature `bin`
+// in the signature domain `domain`, without re-marshaling the model. It returns false if `sig` is
+// not a valid-sized signature encoded last in `bin`.
+//
+// Unlike verifyExtSignature, the returned message is newly allocated, so it can be retained,
+// e.g., by a crpt.BatchVerifier.
+func (u *Util) extSignMessage(domain []byte, bin []byte, sig Signature) ([]byte, bool) {
+	l, ok := noSigLen(bin, sig)
+	if !ok {
+		return nil, false
+	}
+	msg := make([]byte, 0, u.signDomainLen(domain)+l)
+	msg = u.appendSignDomain(msg, domain)
+	return appendNoSigBytes(msg, bin, sig)
+}
+
+// noSigLen returns the length of the CBOR-encoded model without signature given the CBOR-encoded
+// model with signature `bin`. It returns false if `sig` is not a valid-sized signature encoded last
+// in `bin`.
+func noSigLen(bin []byte, sig Signature) (int, bool) {
+	// In CBOR-encoded model bytes:
+	// if the signature is set, it's encoded as a byte string with length 64;
+	// if not, it's a byte string with length 0, not `null`
+	if len(sig) != SignatureCborDataLength ||
+		len(bin) <= SignatureCborInitialLength+SignatureCborDataLength {
+		return 0, false
+	}
+	return len(bin) - SignatureCborDataLength - 1, true
+}
+
+// appendNoSigBytes appends the CBOR-encoded model without signature to `dst`, sliced from the
+// CBOR-encoded model with signature `bin`. It returns false if `sig` is not a valid-sized signature
+// encoded last in `bin`.
+func appendNoSigBytes(dst []byte, bin []byte, sig Signature) ([]byte, bool) {
+	l, ok := noSigLen(bin, sig)
+	if !ok {
+		return dst, false
+	}
+	dst = append(dst, bin[:l-1]...)
+	return append(dst, signatureCborDataLengthByte), true
+}
+
+// appendSignature returns a copy of the CBOR-encoded model without signature `noSig` with the
+// trailing empty byte string replaced by `sig`, which is the same as the CBOR-encoded model with
+// signature because the signature is put last in the CBOR array.
+func appendSignature(noSig []byte, sig Signature) []byte {
+	header := cbg.CborEncodeMajorType(cbg.MajByteString, uint64(len(sig)))
+	bin := make([]byte, 0, len(noSig)-1+len(header)+len(sig))
+	bin = append(bin, noSig[:len(noSig)-1]...)
+	bin = append(bin, header...)
+	return append(bin, sig...)
+}
+
+// signMessage returns the message to be signed for the CBOR-encoded model without signature
+// in the signature domain `domain`, see SignatureDomainVersion for details.
+func (u *Util) signMessage(domain []byte, noSig []byte) []byte {
+	if u.LedgerID == nil || domain == nil {
+		return noSig
+	}
+	msg := make([]byte, 0, u.signDomainLen(domain)+len(noSig))
+	msg = u.appendSignDomain(msg, domain)
+	return append(msg, noSig...)
+}
+
+// signDomainLen returns the length of the prefix appended by appendSignDomain.
+func (u *Util) signDomainLen(domain []byte) int {
+	if u.LedgerID == nil || domain == nil {
+		return 0
+	}
+	return len(domain) + 1 + len(u.LedgerID)
+}
+
+// appendSignDomain appends the signature domain prefix to `msg` if Util.LedgerID is set
+// and `domain` is not nil.
+func (u *Util) appendSignDomain(msg []byte, domain []byte) []byte {
+	if u.LedgerID == nil || domain == nil {
+		return msg
+	}
+	msg = append(msg, domain...)
+	msg = append(msg, SignatureDomainVersion)
+	return append(msg, u.LedgerID...)
+}
+
+// SignBlockHeader signs the block header with `priv` and sets BlockHeader.Sig.
+// The signature is bound to Util.LedgerID if set.
+func (u *Util) SignBlockHeader(bh *BlockHeader, priv crpt.PrivateKey) error {
+	_, err := u.signBlockHeader(bh, priv)
+	return err
+}
+
+// SignBlockHeaderExt signs the block header with `priv`, sets BlockHeader.Sig and refreshes
+// BlockHeaderExt.Bytes and BlockHeaderExt.Hash by appending the signature to the block header bytes.
+// The signature is bound to Util.LedgerID if set.
+func (u *Util) SignBlockHeaderExt(bhx *BlockHeaderExt, priv crpt.PrivateKey) error {
+	bin, err := u.signBlockHeader(bhx.BlockHeader, priv)
+	if err != nil {
+		return err
+	}
+	bhx.Bytes = appendSignature(bin, bhx.Sig)
+	bhx.FullHash, bhx.NoSigHash = nil, nil
+	if u.BlockHashMode == BlockHashNoSig {
+		bhx.NoSigHash = u.Crpt.Hash(bin)
+		bhx.Hash = bhx.NoSigHash
+	} else {
+		bhx.FullHash = u.Crpt.Hash(bhx.Bytes)
+		bhx.Hash = bhx.FullHash
+	}
+	return nil
+}
+
+// signBlockHeader signs the block header and sets BlockHeader.Sig,
+// it also returns the CBOR-encoded block header without signature.
+func (u *Util) signBlockHeader(bh *BlockHeader, priv crpt.PrivateKey) (noSig []byte, err error) {
+	if noSig, err = u.Mrsh.MarshalStruct(getBlockHeaderNoSig(bh)); err != nil {
+		return nil, err
+	}
+	sig, err := priv.SignMessage(u.signMessage(blockHeaderSignatureDomain, noSig), nil)
+	if err != nil {
+		return nil, err
+	}
+	bh.Sig = sig
+	return noSig, nil
+}
+
+// VerifyBlockHeaderSignature verifies the block header signature against BlockHeader.Creator.
+// Should prefer using VerifyBlockHeaderExtSignature instead for better performance.
+func (u *Util) VerifyBlockHeaderSignature(bh *BlockHeader) (bool, error) {
+	bin, err := u.Mrsh.MarshalStruct(getBlockHeaderNoSig(bh))
 	if err != nil {
 		return false, err
 	}
-	return pub.VerifyMessage(txNoSigBytes, txx.Sig)
+
+	pub, err := u.Crpt.PublicKeyFromBytes(bh.Creator)
+	if err != nil {
+		return false, err
+	}
+	return pub.VerifyMessage(u.signMessage(blockHeaderSignatureDomain, bin), bh.Sig)
 }
 
-// HashBlockHeader computes the hash of the BlockHeader.
+// VerifyBlockHeaderExtSignature verifies the block header signature from BlockHeaderExt against
+// BlockHeader.Creator.
+func (u *Util) VerifyBlockHeaderExtSignature(bhx *BlockHeaderExt) (bool, error) {
+	return u.verifyExtSignature(blockHeaderSignatureDomain, bhx.Bytes, bhx.Sig, bhx.Creator)
+}
+
+// HashBlockHeader computes the hash of the BlockHeader according to Util.BlockHashMode.
 func (u *Util) HashBlockHeader(bh *BlockHeader) (BlockHash, error) {
+	if u.BlockHashMode == BlockHashNoSig {
+		return u.HashBlockHeaderNoSig(bh)
+	}
+	return u.HashBlockHeaderFull(bh)
+}
+
+// HashBlockHeaderFull computes the hash of the BlockHeader including signature.
+func (u *Util) HashBlockHeaderFull(bh *BlockHeader) (BlockHash, error) {
 	bin, err := u.Mrsh.MarshalStruct(bh)
 	if err != nil {
 		return nil, err
@@ -311,38 +676,155 @@ func (u *Util) HashBlockHeader(bh *BlockHeader) (BlockHash, error) {
 	return u.Crpt.Hash(bin), nil
 }
 
+// HashBlockHeaderNoSig computes the hash of the BlockHeader without signature.
+func (u *Util) HashBlockHeaderNoSig(bh *BlockHeader) (BlockHash, error) {
+	bin, err := u.Mrsh.MarshalStruct(getBlockHeaderNoSig(bh))
+	if err != nil {
+		return nil, err
+	}
+	return u.Crpt.Hash(bin), nil
+}
+
+// BlockHashOf returns the hash of the BlockHeaderExt chosen by Util.BlockHashMode, see FullHashOf
+// and NoSigHashOf.
+func (u *Util) BlockHashOf(bhx *BlockHeaderExt) (BlockHash, error) {
+	if u.BlockHashMode == BlockHashNoSig {
+		return u.NoSigHashOf(bhx)
+	}
+	return u.FullHashOf(bhx), nil
+}
+
+// FullHashOf returns BlockHeaderExt.FullHash, or computes it from BlockHeaderExt.Bytes if it's not
+// set.
+func (u *Util) FullHashOf(bhx *BlockHeaderExt) BlockHash {
+	if bhx.FullHash != nil {
+		return bhx.FullHash
+	}
+	return u.Crpt.Hash(bhx.Bytes)
+}
+
+// NoSigHashOf returns BlockHeaderExt.NoSigHash, or computes it from BlockHeaderExt.Bytes if it's
+// not set.
+func (u *Util) NoSigHashOf(bhx *BlockHeaderExt) (BlockHash, error) {
+	if bhx.NoSigHash != nil {
+		return bhx.NoSigHash, nil
+	}
+	if len(bhx.Sig) == 0 {
+		return u.Crpt.Hash(bhx.Bytes), nil
+	}
+	if noSig, ok := appendNoSigBytes(make([]byte, 0, len(bhx.Bytes)), bhx.Bytes, bhx.Sig); ok {
+		return u.Crpt.Hash(noSig), nil
+	}
+	return u.HashBlockHeaderNoSig(bhx.BlockHeader)
+}
+
+// ValidatePrevHashes checks that BlockHeader.PrevHashes of `bhx` are exactly the block hashes
+// chosen by Util.BlockHashMode of `parents`, in any order.
+func (u *Util) ValidatePrevHashes(bhx *BlockHeaderExt, parents []*BlockHeaderExt) error {
+	if len(bhx.PrevHashes) != len(parents) {
+		return fmt.Errorf("%w: %d parent headers given for %d PrevHashes",
+			ErrPrevHashesMismatch, len(parents), len(bhx.PrevHashes))
+	}
+	hs := make(map[string]bool, len(parents))
+	for i, p := range parents {
+		h, err := u.BlockHashOf(p)
+		if err != nil {
+			return err
+		}
+		if _, ok := hs[string(h)]; ok {
+			return fmt.Errorf("%w: duplicate parent %d %x", ErrPrevHashesMismatch, i, h)
+		}
+		hs[string(h)] = false
+	}
+	// Both sides have the same size and no duplicates, so they are equal if one contains the other
+	for i, h := range bhx.PrevHashes {
+		seen, ok := hs[string(h)]
+		if !ok {
+			return fmt.Errorf("%w: PrevHashes[%d] %x not found in parents", ErrPrevHashesMismatch, i, h)
+		}
+		if seen {
+			return fmt.Errorf("%w: duplicate PrevHashes[%d] %x", ErrPrevHashesMismatch, i, h)
+		}
+		hs[string(h)] = true
+	}
+	return nil
+}
+
 // ExtendBlockHeader extends a BlockHeader into a BlockHeaderExt.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterBlockHeaderExtra.
 func (u *Util) ExtendBlockHeader(bh *BlockHeader) (*BlockHeaderExt, error) {
 	bin, err := u.Mrsh.MarshalStruct(bh)
 	if err != nil {
 		return nil, err
 	}
-	return &BlockHeaderExt{
+	bhx := &BlockHeaderExt{
 		BlockHeader: bh,
 		Bytes:       bin,
-		Hash:        u.Crpt.Hash(bin),
-	}, nil
+	}
+	if err = u.hashBlockHeaderExt(bhx); err != nil {
+		return nil, err
+	}
+	if bhx.ExtraUnmarshaled, err = u.eagerBlockHeaderExtra(bh); err != nil {
+		return nil, err
+	}
+	return bhx, nil
+}
+
+// hashBlockHeaderExt computes BlockHeaderExt.Hash from BlockHeaderExt.Bytes, and sets either
+// BlockHeaderExt.FullHash or BlockHeaderExt.NoSigHash to it according to Util.BlockHashMode.
+// The other one is left nil and can be computed with FullHashOf or NoSigHashOf.
+func (u *Util) hashBlockHeaderExt(bhx *BlockHeaderExt) error {
+	bhx.FullHash, bhx.NoSigHash = nil, nil
+	h, err := u.BlockHashOf(bhx)
+	if err != nil {
+		return err
+	}
+	if u.BlockHashMode == BlockHashNoSig {
+		bhx.NoSigHash = h
+	} else {
+		bhx.FullHash = h
+	}
+	bhx.Hash = h
+	return nil
 }
 
 // ReadBlockHeaderExtFrom reads and unmarshals the encoded block header from `r`
 // and extends it into a BlockHeaderExt, it also returns the number of bytes read.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterBlockHeaderExtra.
 func (u *Util) ReadBlockHeaderExtFrom(r io.Reader) (bhx *BlockHeaderExt, n int64, err error) {
 	bhx = &BlockHeaderExt{
 		BlockHeader: new(BlockHeader),
 	}
 	var buf bytes.Buffer
-	tr := io.TeeReader(r, &buf)
+	lr := newLimitedReader(r, u.Limits.MaxHeaderBytes)
+	fr := u.Limits.newBlockHeaderFieldReader(lr)
+	tr := io.TeeReader(fr, &buf)
 	n_, err := u.Mrsh.NewDecoder(tr).DecodeStruct(bhx.BlockHeader)
 	n = int64(n_)
-	if err != nil {
+	if lr.exceeded {
+		return nil, n, fmt.Errorf("%w: more than %d bytes", ErrHeaderTooLarge, u.Limits.MaxHeaderBytes)
+	} else if fr.err != nil {
+		return nil, n, fr.err
+	} else if err != nil {
 		return nil, n, err
 	}
 	bhx.Bytes = buf.Bytes()
-	bhx.Hash = u.Crpt.Hash(bhx.Bytes)
+	if err = u.Limits.checkBlockHeader(bhx.BlockHeader, uint64(len(bhx.Bytes))); err != nil {
+		return nil, n, err
+	}
+	if err = u.checkCanonical(bhx.BlockHeader, bhx.Bytes); err != nil {
+		return nil, n, err
+	}
+	if err = u.hashBlockHeaderExt(bhx); err != nil {
+		return nil, n, err
+	}
+	if bhx.ExtraUnmarshaled, err = u.eagerBlockHeaderExtra(bhx.BlockHeader); err != nil {
+		return nil, n, err
+	}
 	return bhx, n, err
 }
 
@@ -350,7 +832,8 @@ func (u *Util) ReadBlockHeaderExtFrom(r io.Reader) (bhx *BlockHeaderExt, n int64
 // BlockHeaderExt.Bytes points to the same underlying memory as `bins` for performance consideration,
 // it's not safe to modify it anywhere.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterBlockHeaderExtra.
 //
 // Deprecated: Use ReadBlockHeaderExtFrom instead.
 func (u *Util) BlockHeaderExtFromBytes(bin []byte) (*BlockHeaderExt, error) {
@@ -362,7 +845,18 @@ func (u *Util) BlockHeaderExtFromBytes(bin []byte) (*BlockHeaderExt, error) {
 		return nil, err
 	}
 	bhx.Bytes = bin[:read]
-	bhx.Hash = u.Crpt.Hash(bin[:read])
+	if err = u.Limits.checkBlockHeader(bhx.BlockHeader, uint64(read)); err != nil {
+		return nil, err
+	}
+	if err = u.checkCanonical(bhx.BlockHeader, bhx.Bytes); err != nil {
+		return nil, err
+	}
+	if err = u.hashBlockHeaderExt(bhx); err != nil {
+		return nil, err
+	}
+	if bhx.ExtraUnmarshaled, err = u.eagerBlockHeaderExtra(bhx.BlockHeader); err != nil {
+		return nil, err
+	}
 	return bhx, err
 }
 
@@ -389,29 +883,41 @@ func (u *Util) ExtendBlock(b *Block) (*BlockExt, error) {
 // ReadBlockHeaderExtFromBlockStream reads and unmarshals the encoded block header from byte stream
 // of a Block and unmarshals it into a BlockHeaderExt.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterBlockHeaderExtra.
 func (u *Util) ReadBlockHeaderExtFromBlockStream(r io.Reader) (bhx *BlockHeaderExt, n int64, err error) {
-	if n, err = io.CopyN(ioutil.Discard, r, BlockCborInitialLength); err != nil || n != BlockHeaderCborInitialLength {
-		return nil, n, err
+	var initial [BlockCborInitialLength]byte
+	read, err := io.ReadFull(r, initial[:])
+	if err != nil {
+		return nil, int64(read), err
+	} else if u.StrictDecoding && initial[0] != BlockCborInitial {
+		return nil, int64(read), fmt.Errorf("%w: block initial byte %#x", ErrNonCanonicalEncoding, initial[0])
 	}
-	bhx, n_, err := u.ReadBlockHeaderExtFrom(r)
-	return bhx, n + n_, err
+	bhx, n, err = u.ReadBlockHeaderExtFrom(r)
+	return bhx, n + int64(read), err
 }
 
 // ExtractBlockHeaderExtFromBlockBytes extracts bytes corresponding to the
 // BlockHeader from Block bytes and unmarshals it into a BlockHeaderExt.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if an ExtraCtor is registered and Util.LazyExtra is false,
+// see RegisterBlockHeaderExtra.
 //
 // Deprecated: Use ReadBlockHeaderExtFromBlockStream instead.
 func (u *Util) ExtractBlockHeaderExtFromBlockBytes(bin []byte) (*BlockHeaderExt, error) {
+	if len(bin) < BlockCborInitialLength {
+		return nil, ErrInvalidBytes
+	} else if u.StrictDecoding && bin[0] != BlockCborInitial {
+		return nil, fmt.Errorf("%w: block initial byte %#x", ErrNonCanonicalEncoding, bin[0])
+	}
 	return u.BlockHeaderExtFromBytes(bin[BlockCborInitialLength:])
 }
 
 // ReadBlockExtFrom reads and unmarshals the encoded block from `r` and extends it into a BlockExt,
 // it also returns the number of bytes read.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if ExtraCtors are registered, see RegisterTransactionExtra
+// and RegisterBlockHeaderExtra.
 func (u *Util) ReadBlockExtFrom(r io.Reader) (bx *BlockExt, n int64, err error) {
 	bx = &BlockExt{
 		util: u,
@@ -436,7 +942,8 @@ func (u *Util) ReadBlockExtFrom(r io.Reader) (bx *BlockExt, n int64, err error)
 // BlockHeaderExt.Bytes and TransactionExt.Bytes point to the same underlying memory as `bins` for
 // performance consideration, it's not safe to modify them anywhere.
 //
-// NOTE: ExtraUnmarshaled is not set yet.
+// NOTE: ExtraUnmarshaled is set only if ExtraCtors are registered, see RegisterTransactionExtra
+// and RegisterBlockHeaderExtra.
 //
 // Deprecated: Use ReadBlockExtFrom instead.
 func (u *Util) BlockExtFromBytes(bin []byte) (bx *BlockExt, err error) {
@@ -459,6 +966,8 @@ func (u *Util) BlockExtFromBytes(bin []byte) (bx *BlockExt, err error) {
 		if err != nil {
 			return nil, err
 		}
+	} else if i+CborNullLength != len(bin) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTrailingBytes, len(bin)-i-CborNullLength)
 	}
 
 	return bx, nil
@@ -502,3 +1011,16 @@ func getTxNoSig(tx *Transaction) (txNoSig *Transaction) {
 	txNoSig.Sig = nil
 	return txNoSig
 }
+
+// If bh contains signature, return a copy without the signature.
+func getBlockHeaderNoSig(bh *BlockHeader) (bhNoSig *BlockHeader) {
+	// Don't need to copy BlockHeader
+	if bh.Sig == nil {
+		return bh
+	}
+
+	bhNoSig = new(BlockHeader)
+	*bhNoSig = *bh
+	bhNoSig.Sig = nil
+	return bhNoSig
+}
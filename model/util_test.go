@@ -30,6 +30,141 @@ func TestUtil(t *testing.T) {
 		assr.True(ok)
 	})
 
+	t.Run("Transaction signing", func(t *testing.T) {
+		tx := test.TestTransaction
+		tx.Sig = nil
+		txx, err := ut.ExtendTransaction(&tx)
+		req.NoError(err)
+		req.NoError(ut.SignTransactionExt(txx, test.TestPrivateKey))
+		assr.Equal(test.TestTransaction.Sig, txx.Sig)
+
+		txx_, err := ut.ExtendTransaction(&test.TestTransaction)
+		req.NoError(err)
+		assr.Equal(txx_.Bytes, txx.Bytes)
+		assr.Equal(txx_.Hash, txx.Hash)
+		ok, err := ut.VerifyTransactionExtSignature(txx)
+		assr.NoError(err)
+		assr.True(ok)
+	})
+
+	t.Run("BlockHeader signing", func(t *testing.T) {
+		bh := test.TestBlockHeader
+		ok, err := ut.VerifyBlockHeaderSignature(&bh)
+		assr.NoError(err)
+		assr.True(ok)
+
+		bh.Sig = nil
+		bhx, err := ut.ExtendBlockHeader(&bh)
+		req.NoError(err)
+		ok, err = ut.VerifyBlockHeaderExtSignature(bhx)
+		assr.NoError(err)
+		assr.False(ok)
+
+		req.NoError(ut.SignBlockHeaderExt(bhx, test.TestPrivateKey))
+		bhx_, err := ut.ExtendBlockHeader(&test.TestBlockHeader)
+		req.NoError(err)
+		assr.Equal(bhx_.Bytes, bhx.Bytes)
+		assr.Equal(bhx_.Hash, bhx.Hash)
+		ok, err = ut.VerifyBlockHeaderExtSignature(bhx)
+		assr.NoError(err)
+		assr.True(ok)
+
+		// Signature by other than the creator should fail
+		_, priv, err := test.Crpt.GenerateKey(nil)
+		req.NoError(err)
+		req.NoError(ut.SignBlockHeaderExt(bhx, priv))
+		ok, err = ut.VerifyBlockHeaderExtSignature(bhx)
+		assr.NoError(err)
+		assr.False(ok)
+	})
+
+	t.Run("Block hash modes", func(t *testing.T) {
+		noSigUt := New(test.Mrsh, test.Crpt)
+		noSigUt.BlockHashMode = BlockHashNoSig
+
+		bh := test.TestBlockHeader
+		bhx, err := ut.ExtendBlockHeader(&bh)
+		req.NoError(err)
+		bhx_, err := noSigUt.ExtendBlockHeader(&bh)
+		req.NoError(err)
+		assr.Equal(bhx.FullHash, bhx.Hash)
+		assr.Equal(bhx_.NoSigHash, bhx_.Hash)
+		assr.NotEqual(bhx.Hash, bhx_.Hash)
+
+		// The hash of the other mode is computed lazily
+		assr.Nil(bhx.NoSigHash)
+		assr.Nil(bhx_.FullHash)
+		assr.Equal(bhx.FullHash, ut.FullHashOf(bhx_))
+		noSigHash, err := ut.NoSigHashOf(bhx)
+		req.NoError(err)
+		assr.Equal(bhx_.NoSigHash, noSigHash)
+
+		h, err := ut.HashBlockHeader(&bh)
+		req.NoError(err)
+		assr.Equal(bhx.FullHash, h)
+		h, err = noSigUt.HashBlockHeader(&bh)
+		req.NoError(err)
+		assr.Equal(noSigHash, h)
+
+		// NoSigHash shouldn't change after re-signing
+		_, priv, err := test.Crpt.GenerateKey(nil)
+		req.NoError(err)
+		resigned, err := noSigUt.ExtendBlockHeader(&bh)
+		req.NoError(err)
+		bh_ := bh
+		resigned.BlockHeader = &bh_
+		req.NoError(noSigUt.SignBlockHeaderExt(resigned, priv))
+		assr.Equal(noSigHash, resigned.Hash)
+		assr.NotEqual(bhx.FullHash, ut.FullHashOf(resigned))
+
+		child := test.GenTestBlockHeaderWithExtra(nil)
+		child.PrevHashes = []BlockHash{noSigHash}
+		childx, err := noSigUt.ExtendBlockHeader(child)
+		req.NoError(err)
+		assr.NoError(noSigUt.ValidatePrevHashes(childx, []*BlockHeaderExt{bhx}))
+		assr.NoError(noSigUt.ValidatePrevHashes(childx, []*BlockHeaderExt{bhx_}))
+		assr.ErrorIs(ut.ValidatePrevHashes(childx, []*BlockHeaderExt{bhx}), ErrPrevHashesMismatch)
+		assr.ErrorIs(noSigUt.ValidatePrevHashes(childx, nil), ErrPrevHashesMismatch)
+
+		// Duplicates on either side
+		otherx, err := noSigUt.ExtendBlockHeader(test.GenTestBlockHeaderWithExtra(nil))
+		req.NoError(err)
+		child.PrevHashes = []BlockHash{noSigHash, noSigHash}
+		childx, err = noSigUt.ExtendBlockHeader(child)
+		req.NoError(err)
+		assr.ErrorIs(noSigUt.ValidatePrevHashes(childx, []*BlockHeaderExt{bhx, otherx}), ErrPrevHashesMismatch)
+		child.PrevHashes = []BlockHash{noSigHash, otherx.NoSigHash}
+		childx, err = noSigUt.ExtendBlockHeader(child)
+		req.NoError(err)
+		assr.NoError(noSigUt.ValidatePrevHashes(childx, []*BlockHeaderExt{otherx, bhx}))
+		assr.ErrorIs(noSigUt.ValidatePrevHashes(childx, []*BlockHeaderExt{bhx, bhx}), ErrPrevHashesMismatch)
+	})
+
+	t.Run("Domain-separated signature", func(t *testing.T) {
+		ledgerUt := New(test.Mrsh, test.Crpt)
+		ledgerUt.LedgerID = test.TestHash
+		otherUt := New(test.Mrsh, test.Crpt)
+		otherUt.LedgerID = test.TestHash2
+
+		tx := test.TestTransaction
+		req.NoError(ledgerUt.SignTransaction(&tx, test.TestPrivateKey))
+		assr.NotEqual(test.TestTransaction.Sig, tx.Sig)
+		txx, err := ledgerUt.ExtendTransaction(&tx)
+		req.NoError(err)
+
+		assr.NoError(ledgerUt.VerifyTransaction(&tx))
+		ok, err := ledgerUt.VerifyTransactionExtSignature(txx)
+		assr.NoError(err)
+		assr.True(ok)
+
+		// Replaying on other ledgers should fail
+		assr.ErrorIs(otherUt.VerifyTransaction(&tx), ErrInvalidSignature)
+		ok, err = otherUt.VerifyTransactionExtSignature(txx)
+		assr.NoError(err)
+		assr.False(ok)
+		assr.ErrorIs(ut.VerifyTransaction(&tx), ErrInvalidSignature)
+	})
+
 	t.Run("GenRootHashFromXxx", func(t *testing.T) {
 		txs := test.TestTransactionSlice
 		txhs := make([]TransactionHash, len(txs))
@@ -49,6 +184,33 @@ func TestUtil(t *testing.T) {
 		assr.Equal(h, h_)
 	})
 
+	t.Run("Parallel extension and hashing", func(t *testing.T) {
+		txs := make(TransactionSlice, 100)
+		for i := range txs {
+			txs[i] = *test.GenRandomTransaction()
+		}
+		txxs, err := ut.ExtendTransactionSlice(txs)
+		req.NoError(err)
+		hs, err := ut.HashTransactionSlice(txs)
+		req.NoError(err)
+		root, err := ut.GenRootHashFromTransactionSlice(txs)
+		req.NoError(err)
+
+		for _, workers := range []int{0, 1, 3, 200} {
+			pUt := New(test.Mrsh, test.Crpt)
+			pUt.Workers = workers
+			txxs_, err := pUt.ExtendTransactionSliceParallel(txs)
+			req.NoError(err)
+			assr.Equal(txxs, txxs_)
+			hs_, err := pUt.HashTransactionSliceParallel(txs)
+			req.NoError(err)
+			assr.Equal(hs, hs_)
+			root_, err := pUt.GenRootHashFromTransactionSliceParallel(txs)
+			req.NoError(err)
+			assr.Equal(root, root_)
+		}
+	})
+
 	t.Run("Block bytes operations", func(t *testing.T) {
 		b := &Block{
 			Header: &test.TestBlockHeader,
@@ -87,3 +249,27 @@ func TestUtil(t *testing.T) {
 		assr.Equal(bx, bx_) // bx_.block should equal to `b` now
 	})
 }
+
+func TestVerifyTransactionExtSlice(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+	ut := test.Util
+
+	txxs := make(TransactionExtSlice, 20)
+	for i := range txxs {
+		txx, err := ut.ExtendTransaction(test.GenSignedTransaction(uint64(i)))
+		req.NoError(err)
+		txxs[i] = txx
+	}
+	assr.Nil(ut.VerifyTransactionExtSlice(txxs))
+	assr.Nil(ut.VerifyTransactionExtSlice(nil))
+
+	_, priv, err := test.Crpt.GenerateKey(nil)
+	req.NoError(err)
+	req.NoError(ut.SignTransactionExt(txxs[3], priv)) // Signed by others
+	txxs[7].Sig = nil                                 // Missing signature
+	txxs[12].Sig = append(Signature{}, txxs[12].Sig...)
+	txxs[12].Sig[0]++ // Tampered signature
+	txxs[15].From = txxs[15].From[1:]
+	assr.Equal([]int{3, 7, 12, 15}, ut.VerifyTransactionExtSlice(txxs))
+}
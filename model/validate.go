@@ -0,0 +1,152 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingBlockHeader          = errors.New("block header is missing")
+	ErrTxCountMismatch             = errors.New("transaction count mismatch")
+	ErrTxRootMismatch              = errors.New("transactions root hash mismatch")
+	ErrInvalidHashSize             = errors.New("invalid hash size")
+	ErrMalformedPrevHashes         = errors.New("malformed previous block hashes")
+	ErrInvalidHeaderSignature      = errors.New("invalid block header signature")
+	ErrInvalidTransactionSignature = errors.New("invalid transaction signature")
+	ErrHeaderBytesMismatch         = errors.New("block header doesn't match its encoded bytes")
+	ErrTxHashMismatch              = errors.New("transaction hash mismatch")
+	ErrTxBytesMismatch             = errors.New("transaction doesn't match its encoded bytes")
+)
+
+// CheckTransactionExt checks that the Transaction and TransactionExt.Hash of `txx` match
+// TransactionExt.Bytes, over which the hash and signature are computed. It returns an error
+// wrapping one of the following errors:
+//
+//   - ErrTxBytesMismatch: the Transaction doesn't match TransactionExt.Bytes.
+//   - ErrInvalidHashSize: TransactionExt.Hash is of wrong size.
+//   - ErrTxHashMismatch: TransactionExt.Hash is not the hash of TransactionExt.Bytes.
+func (u *Util) CheckTransactionExt(txx *TransactionExt) error {
+	if txx == nil || txx.Transaction == nil {
+		return fmt.Errorf("%w: no transaction", ErrTxBytesMismatch)
+	}
+	if bin, err := u.Mrsh.MarshalStruct(txx.Transaction); err != nil {
+		return fmt.Errorf("%w: %v", ErrTxBytesMismatch, err)
+	} else if !bytes.Equal(bin, txx.Bytes) {
+		return ErrTxBytesMismatch
+	}
+	if len(txx.Hash) != HashSize {
+		return fmt.Errorf("%w: transaction hash has size %d, expected %d",
+			ErrInvalidHashSize, len(txx.Hash), HashSize)
+	}
+	if h := u.Crpt.Hash(txx.Bytes); !bytes.Equal(h, txx.Hash) {
+		return fmt.Errorf("%w: %x, computed %x", ErrTxHashMismatch, txx.Hash, h)
+	}
+	return nil
+}
+
+// ValidateOptions specifies the optional checks performed by Util.ValidateBlockExt.
+type ValidateOptions struct {
+	// SkipHeaderSig skips verifying the block header signature.
+	SkipHeaderSig bool
+
+	// SkipTxSigs skips verifying the transaction signatures.
+	SkipTxSigs bool
+}
+
+// ValidateBlockExt checks that the BlockExt is internally consistent, it returns the first
+// violation found wrapping one of the following errors:
+//
+//   - ErrMissingBlockHeader: BlockExt.Header is not set.
+//   - ErrMalformedPrevHashes: BlockHeader.PrevHashes contains hashes of wrong size or duplicates.
+//   - ErrTxCountMismatch: BlockHeader.TxCount doesn't equal the number of transactions.
+//   - ErrInvalidHashSize: BlockHeader.TxRoot or a TransactionExt.Hash is of wrong size.
+//   - ErrTxBytesMismatch, ErrTxHashMismatch: a TransactionExt is inconsistent, see
+//     CheckTransactionExt.
+//   - ErrTxRootMismatch: BlockHeader.TxRoot doesn't match the transactions.
+//   - ErrInvalidGenesis: a genesis transaction is in a block other than the genesis block (at
+//     Height 0), or it doesn't create the ledger of Util.LedgerID.
+//   - ErrHeaderBytesMismatch: the BlockHeader doesn't match BlockHeaderExt.Bytes, against which the
+//     signature is verified.
+//   - ErrInvalidHeaderSignature: the block header signature is invalid.
+//   - ErrInvalidTransactionSignature: a transaction signature is invalid.
+func (u *Util) ValidateBlockExt(bx *BlockExt, opts ValidateOptions) error {
+	bhx := bx.Header
+	if bhx == nil || bhx.BlockHeader == nil {
+		return ErrMissingBlockHeader
+	}
+
+	hs := make(map[string]struct{}, len(bhx.PrevHashes))
+	for i, h := range bhx.PrevHashes {
+		if len(h) != HashSize {
+			return fmt.Errorf("%w: PrevHashes[%d] has size %d, expected %d",
+				ErrMalformedPrevHashes, i, len(h), HashSize)
+		}
+		if _, ok := hs[string(h)]; ok {
+			return fmt.Errorf("%w: duplicate PrevHashes[%d] %x", ErrMalformedPrevHashes, i, h)
+		}
+		hs[string(h)] = struct{}{}
+	}
+
+	if bhx.TxCount != uint64(len(bx.Txs)) {
+		return fmt.Errorf("%w: header has %d, block has %d", ErrTxCountMismatch, bhx.TxCount, len(bx.Txs))
+	}
+
+	if len(bhx.TxRoot) != HashSize {
+		return fmt.Errorf("%w: TxRoot has size %d, expected %d", ErrInvalidHashSize, len(bhx.TxRoot), HashSize)
+	}
+	for i, txx := range bx.Txs {
+		if err := u.CheckTransactionExt(txx); err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	if root := u.GenRootHashFromTransactionExtSlice(bx.Txs); !bytes.Equal(root, bhx.TxRoot) {
+		return fmt.Errorf("%w: header has %x, computed %x", ErrTxRootMismatch, bhx.TxRoot, root)
+	}
+
+	// Genesis transactions are not bound to any ledger, so they are only allowed in the genesis
+	// block, see SignatureDomainVersion.
+	genesis := -1
+	for i, txx := range bx.Txs {
+		if txx.Type != TransactionTypeGenesis {
+			continue
+		}
+		if bhx.Height != 0 || genesis >= 0 {
+			return fmt.Errorf("%w: transaction %d in block at height %d", ErrInvalidGenesis, i, bhx.Height)
+		}
+		genesis = i
+		if u.LedgerID != nil && !bytes.Equal(txx.Hash, u.LedgerID) {
+			return fmt.Errorf("%w: transaction %d creates ledger %x, expected %x",
+				ErrInvalidGenesis, i, txx.Hash, u.LedgerID)
+		}
+	}
+
+	// The checks above are on the decoded BlockHeader, while the signature is verified against
+	// BlockHeaderExt.Bytes, so they must agree.
+	if bin, err := u.Mrsh.MarshalStruct(bhx.BlockHeader); err != nil {
+		return fmt.Errorf("%w: %v", ErrHeaderBytesMismatch, err)
+	} else if !bytes.Equal(bin, bhx.Bytes) {
+		return ErrHeaderBytesMismatch
+	}
+
+	if !opts.SkipHeaderSig {
+		if ok, err := u.VerifyBlockHeaderExtSignature(bhx); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidHeaderSignature, err)
+		} else if !ok {
+			return ErrInvalidHeaderSignature
+		}
+	}
+
+	if !opts.SkipTxSigs {
+		for _, i := range u.VerifyTransactionExtSlice(bx.Txs) {
+			if i == genesis {
+				if _, _, err := u.VerifyGenesis(bx.Txs[i]); err == nil {
+					continue
+				}
+			}
+			return fmt.Errorf("%w: transaction %d", ErrInvalidTransactionSignature, i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestValidateBlockExt(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+	ut := test.Util
+
+	gen := func() *BlockExt {
+		return test.GenSignedBlock([]BlockHash{test.TestHash, test.TestHash2}, 1, 0, 3)
+	}
+
+	t.Run("Valid block", func(t *testing.T) {
+		assr.NoError(ut.ValidateBlockExt(gen(), ValidateOptions{}))
+		assr.NoError(ut.ValidateBlockExt(test.GenSignedBlock(nil, 0, 0, 0), ValidateOptions{}))
+	})
+
+	t.Run("Malformed PrevHashes", func(t *testing.T) {
+		bx := gen()
+		bx.Header.PrevHashes = []BlockHash{test.TestHash, test.TestHash}
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrMalformedPrevHashes)
+		bx.Header.PrevHashes = []BlockHash{test.TestHash[1:]}
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrMalformedPrevHashes)
+	})
+
+	t.Run("Count mismatch", func(t *testing.T) {
+		bx := gen()
+		bx.Txs = bx.Txs[1:]
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrTxCountMismatch)
+	})
+
+	t.Run("Root mismatch", func(t *testing.T) {
+		bx := gen()
+		bx.Header.TxRoot = test.TestHash
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrTxRootMismatch)
+		bx.Header.TxRoot = test.TestHash[1:]
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrInvalidHashSize)
+	})
+
+	t.Run("Transaction hash mismatch", func(t *testing.T) {
+		bx := gen()
+		bx.Txs[1].Hash = test.TestHash
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrTxHashMismatch)
+		bx.Txs[1].Hash = test.TestHash[1:]
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrInvalidHashSize)
+	})
+
+	t.Run("Transaction bytes mismatch", func(t *testing.T) {
+		// The signed bytes and hash are still those of the original Transaction
+		bx := gen()
+		bx.Txs[1].Nonce++
+		err := ut.ValidateBlockExt(bx, ValidateOptions{})
+		assr.ErrorIs(err, ErrTxBytesMismatch)
+		assr.Contains(err.Error(), "transaction 1")
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{SkipTxSigs: true}), ErrTxBytesMismatch)
+		assr.ErrorIs(ut.CheckTransactionExt(bx.Txs[1]), ErrTxBytesMismatch)
+		assr.NoError(ut.CheckTransactionExt(bx.Txs[0]))
+	})
+
+	t.Run("Header bytes mismatch", func(t *testing.T) {
+		// Swap a transaction and only fix the decoded header, the signed bytes still commit to the
+		// original transactions.
+		bx := gen()
+		bx.Txs[1] = test.GenSignedBlock(nil, 0, 10, 1).Txs[0]
+		bx.Header.TxRoot = ut.GenRootHashFromTransactionExtSlice(bx.Txs)
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrHeaderBytesMismatch)
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{SkipHeaderSig: true}), ErrHeaderBytesMismatch)
+	})
+
+	t.Run("Bad header signature", func(t *testing.T) {
+		bx := gen()
+		_, priv, err := test.Crpt.GenerateKey(nil)
+		req.NoError(err)
+		req.NoError(ut.SignBlockHeaderExt(bx.Header, priv))
+		assr.ErrorIs(ut.ValidateBlockExt(bx, ValidateOptions{}), ErrInvalidHeaderSignature)
+		assr.NoError(ut.ValidateBlockExt(bx, ValidateOptions{SkipHeaderSig: true}))
+	})
+
+	t.Run("Bad transaction signature", func(t *testing.T) {
+		bx := gen()
+		txx := bx.Txs[1]
+		_, priv, err := test.Crpt.GenerateKey(nil)
+		req.NoError(err)
+		req.NoError(ut.SignTransactionExt(txx, priv))
+		bx.Header.TxRoot = ut.GenRootHashFromTransactionExtSlice(bx.Txs)
+		req.NoError(ut.SignBlockHeaderExt(bx.Header, test.TestPrivateKey))
+		err = ut.ValidateBlockExt(bx, ValidateOptions{})
+		assr.ErrorIs(err, ErrInvalidTransactionSignature)
+		assr.Contains(err.Error(), "transaction 1")
+		assr.NoError(ut.ValidateBlockExt(bx, ValidateOptions{SkipTxSigs: true}))
+	})
+}
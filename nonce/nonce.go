@@ -0,0 +1,243 @@
+// Package nonce tracks the nonces of the Transactions from every address to prevent replays.
+package nonce
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	m "github.com/daotl/go-doubl/model"
+)
+
+var (
+	ErrNonceUsed    = errors.New("nonce already used")
+	ErrNonceGap     = errors.New("nonce gap")
+	ErrInvalidBlock = errors.New("invalid block")
+	ErrUnknownBlock = errors.New("block not applied or can't be rolled back to")
+)
+
+// Policy specifies which nonces of the Transactions from an address are accepted.
+type Policy uint8
+
+const (
+	// Strict only accepts the nonces of the Transactions from every address to be sequential
+	// starting from 0, so a Transaction is rejected if its nonce has been used or is greater than
+	// the next expected nonce.
+	Strict Policy = iota
+
+	// UniqueOnly accepts any nonce that has not been used by the same address.
+	UniqueOnly
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Strict:
+		return "Strict"
+	case UniqueOnly:
+		return "UniqueOnly"
+	default:
+		return fmt.Sprintf("Policy(%d)", uint8(p))
+	}
+}
+
+// DefaultMaxRollback is the default Tracker.MaxRollback.
+const DefaultMaxRollback = 100
+
+// change records a nonce used by a Transaction, so that it can be undone.
+type change struct {
+	addr     string
+	nonce    uint64
+	prevNext uint64
+}
+
+// undo records the changes made by an applied block.
+type undo struct {
+	hash    m.BlockHash
+	changes []change
+}
+
+// Tracker tracks the nonces used by the Transactions from every address in the applied blocks.
+// It's safe for concurrent use.
+//
+// Blocks should be applied in the execution order (e.g., the order of a dag.Linearizer). Check can
+// be used for mempool admission, and CheckBlock and ApplyBlock for block validation.
+type Tracker struct {
+	// Maximum number of the latest applied blocks that can be rolled back, DefaultMaxRollback by
+	// default. A negative value means unlimited, with which the changes made by every applied
+	// block are kept in memory.
+	MaxRollback int
+
+	policy  Policy
+	mtx     sync.RWMutex
+	next    map[string]uint64
+	used    map[string]map[uint64]struct{}
+	journal []undo
+	base    m.BlockHash // the last applied block before the journal, nil if none
+}
+
+// New creates a new Tracker with `policy`.
+func New(policy Policy) *Tracker {
+	return &Tracker{
+		MaxRollback: DefaultMaxRollback,
+		policy:      policy,
+		next:        make(map[string]uint64),
+		used:        make(map[string]map[uint64]struct{}),
+	}
+}
+
+// Policy returns the Policy of the Tracker.
+func (t *Tracker) Policy() Policy {
+	return t.policy
+}
+
+// Next returns the next expected nonce of `addr`, which is greater than all the used nonces.
+func (t *Tracker) Next(addr m.Address) uint64 {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.next[string(addr)]
+}
+
+// Check checks whether a Transaction from `addr` with `nonce` can be applied now.
+// It returns ErrNonceUsed if `nonce` has been used, or ErrNonceGap if `nonce` is greater than
+// the next expected nonce under the Strict policy.
+func (t *Tracker) Check(addr m.Address, nonce uint64) error {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.check(string(addr), nonce)
+}
+
+func (t *Tracker) check(addr string, nonce uint64) error {
+	switch t.policy {
+	case Strict:
+		next := t.next[addr]
+		if nonce < next {
+			return fmt.Errorf("%w: nonce %d of %x, expected %d", ErrNonceUsed, nonce, addr, next)
+		} else if nonce > next {
+			return fmt.Errorf("%w: nonce %d of %x, expected %d", ErrNonceGap, nonce, addr, next)
+		}
+	default:
+		if _, ok := t.used[addr][nonce]; ok {
+			return fmt.Errorf("%w: nonce %d of %x", ErrNonceUsed, nonce, addr)
+		}
+	}
+	return nil
+}
+
+// CheckBlock checks whether all the Transactions in `bx` can be applied in order, without applying
+// them.
+func (t *Tracker) CheckBlock(bx *m.BlockExt) error {
+	if err := checkBlock(bx); err != nil {
+		return err
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	changes, err := t.apply(bx.Txs)
+	t.revert(changes)
+	return err
+}
+
+// ApplyBlock applies all the Transactions in `bx` in order. Nothing is applied if any of them
+// fails the check.
+func (t *Tracker) ApplyBlock(bx *m.BlockExt) error {
+	if err := checkBlock(bx); err != nil {
+		return err
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	changes, err := t.apply(bx.Txs)
+	if err != nil {
+		t.revert(changes)
+		return err
+	}
+	t.journal = append(t.journal, undo{hash: bx.Header.Hash, changes: changes})
+	if t.MaxRollback >= 0 && len(t.journal) > t.MaxRollback {
+		drop := len(t.journal) - t.MaxRollback
+		t.base = t.journal[drop-1].hash
+		t.journal = append(t.journal[:0:0], t.journal[drop:]...)
+	}
+	return nil
+}
+
+// Rollback reverts the blocks applied after the block with hash `hash`, so the state becomes the
+// same as right after it was applied, or reverts all the applied blocks if `hash` is nil. It
+// returns ErrUnknownBlock if the block was not applied, or more than MaxRollback blocks would be
+// reverted.
+func (t *Tracker) Rollback(hash m.BlockHash) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	i := len(t.journal) - 1
+	for ; i >= 0; i-- {
+		if bytes.Equal(t.journal[i].hash, hash) {
+			break
+		}
+	}
+	if i < 0 && !bytes.Equal(t.base, hash) {
+		return fmt.Errorf("%w: %x", ErrUnknownBlock, hash)
+	}
+	for j := len(t.journal) - 1; j > i; j-- {
+		t.revert(t.journal[j].changes)
+	}
+	t.journal = t.journal[:i+1]
+	return nil
+}
+
+// LastBlock returns the hash of the last applied block that has not been rolled back, or nil if
+// there is none.
+func (t *Tracker) LastBlock() m.BlockHash {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	if len(t.journal) == 0 {
+		return t.base
+	}
+	return t.journal[len(t.journal)-1].hash
+}
+
+// apply checks and applies `txs` in order until one fails the check, and returns the applied
+// changes.
+func (t *Tracker) apply(txs m.TransactionExtSlice) ([]change, error) {
+	changes := make([]change, 0, len(txs))
+	for i, txx := range txs {
+		addr := string(txx.From)
+		if err := t.check(addr, txx.Nonce); err != nil {
+			return changes, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		prevNext := t.next[addr]
+		if txx.Nonce >= prevNext {
+			t.next[addr] = txx.Nonce + 1
+		}
+		if t.policy == UniqueOnly {
+			if t.used[addr] == nil {
+				t.used[addr] = make(map[uint64]struct{})
+			}
+			t.used[addr][txx.Nonce] = struct{}{}
+		}
+		changes = append(changes, change{addr: addr, nonce: txx.Nonce, prevNext: prevNext})
+	}
+	return changes, nil
+}
+
+// revert undoes `changes` in reverse order.
+func (t *Tracker) revert(changes []change) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if c.prevNext == 0 {
+			delete(t.next, c.addr)
+		} else {
+			t.next[c.addr] = c.prevNext
+		}
+		if t.policy == UniqueOnly {
+			delete(t.used[c.addr], c.nonce)
+			if len(t.used[c.addr]) == 0 {
+				delete(t.used, c.addr)
+			}
+		}
+	}
+}
+
+func checkBlock(bx *m.BlockExt) error {
+	if bx == nil || bx.Header == nil || len(bx.Header.Hash) == 0 {
+		return fmt.Errorf("%w: no block hash", ErrInvalidBlock)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package nonce_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	m "github.com/daotl/go-doubl/model"
+	. "github.com/daotl/go-doubl/nonce"
+	"github.com/daotl/go-doubl/test"
+)
+
+func TestStrict(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	tr := New(Strict)
+	addr := test.TestAddress
+	assr.Equal(uint64(0), tr.Next(addr))
+	assr.NoError(tr.Check(addr, 0))
+	assr.ErrorIs(tr.Check(addr, 1), ErrNonceGap)
+
+	b1 := test.GenSignedBlock(nil, 0, 0, 3)
+	b2 := test.GenSignedBlock([]m.BlockHash{b1.Header.Hash}, 1, 3, 2)
+	req.NoError(tr.CheckBlock(b1))
+	assr.Equal(uint64(0), tr.Next(addr))
+	assr.ErrorIs(tr.CheckBlock(b2), ErrNonceGap)
+
+	req.NoError(tr.ApplyBlock(b1))
+	assr.Equal(uint64(3), tr.Next(addr))
+	assr.ErrorIs(tr.Check(addr, 2), ErrNonceUsed)
+	assr.NoError(tr.Check(addr, 3))
+	assr.ErrorIs(tr.Check(addr, 4), ErrNonceGap)
+	assr.NoError(tr.Check(test.TestAddress2, 0))
+	assr.ErrorIs(tr.ApplyBlock(b1), ErrNonceUsed)
+	assr.Equal(b1.Header.Hash, tr.LastBlock())
+
+	// Nothing is applied if any Transaction fails
+	b3 := test.GenSignedBlock(nil, 2, 5, 2)
+	b3.Txs = append(m.TransactionExtSlice{b2.Txs[0]}, b3.Txs...)
+	assr.ErrorIs(tr.ApplyBlock(b3), ErrNonceGap)
+	assr.Equal(uint64(3), tr.Next(addr))
+
+	req.NoError(tr.ApplyBlock(b2))
+	assr.Equal(uint64(5), tr.Next(addr))
+	req.NoError(tr.Rollback(b1.Header.Hash))
+	assr.Equal(uint64(3), tr.Next(addr))
+	assr.Equal(b1.Header.Hash, tr.LastBlock())
+	assr.ErrorIs(tr.Rollback(b2.Header.Hash), ErrUnknownBlock)
+	req.NoError(tr.ApplyBlock(b2))
+	assr.Equal(uint64(5), tr.Next(addr))
+
+	// Roll back all the applied blocks
+	req.NoError(tr.Rollback(nil))
+	assr.Equal(uint64(0), tr.Next(addr))
+	assr.Nil(tr.LastBlock())
+	req.NoError(tr.ApplyBlock(b1))
+
+	assr.ErrorIs(tr.ApplyBlock(&m.BlockExt{}), ErrInvalidBlock)
+}
+
+func TestUniqueOnly(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	tr := New(UniqueOnly)
+	addr := test.TestAddress
+	assr.NoError(tr.Check(addr, 10))
+
+	b1 := test.GenSignedBlock(nil, 0, 10, 2)
+	b2 := test.GenSignedBlock([]m.BlockHash{b1.Header.Hash}, 1, 0, 2)
+	req.NoError(tr.ApplyBlock(b1))
+	assr.Equal(uint64(12), tr.Next(addr))
+	req.NoError(tr.ApplyBlock(b2))
+	assr.Equal(uint64(12), tr.Next(addr))
+	for _, n := range []uint64{0, 1, 10, 11} {
+		assr.ErrorIs(tr.Check(addr, n), ErrNonceUsed)
+	}
+	assr.NoError(tr.Check(addr, 2))
+	assr.NoError(tr.Check(addr, 100))
+
+	// Duplicate nonces in one block
+	b3 := test.GenSignedBlock(nil, 2, 20, 1)
+	b3.Txs = append(b3.Txs, b3.Txs[0])
+	assr.ErrorIs(tr.CheckBlock(b3), ErrNonceUsed)
+	assr.NoError(tr.Check(addr, 20))
+
+	req.NoError(tr.Rollback(b1.Header.Hash))
+	assr.NoError(tr.Check(addr, 0))
+	assr.ErrorIs(tr.Check(addr, 10), ErrNonceUsed)
+	assr.Equal(uint64(12), tr.Next(addr))
+}
+
+func TestMaxRollback(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	tr := New(Strict)
+	assr.Equal(DefaultMaxRollback, tr.MaxRollback)
+	tr.MaxRollback = 2
+	var bxs []*m.BlockExt
+	for i := 0; i < 4; i++ {
+		bx := test.GenSignedBlock(nil, m.BlockHeight(i), uint64(i), 1)
+		req.NoError(tr.ApplyBlock(bx))
+		bxs = append(bxs, bx)
+	}
+	assr.ErrorIs(tr.Rollback(bxs[0].Header.Hash), ErrUnknownBlock)
+	assr.ErrorIs(tr.Rollback(nil), ErrUnknownBlock)
+	req.NoError(tr.Rollback(bxs[2].Header.Hash))
+	assr.Equal(uint64(3), tr.Next(test.TestAddress))
+	req.NoError(tr.Rollback(bxs[2].Header.Hash))
+	req.NoError(tr.Rollback(bxs[1].Header.Hash))
+	assr.Equal(uint64(2), tr.Next(test.TestAddress))
+	assr.Equal(bxs[1].Header.Hash, tr.LastBlock())
+	assr.ErrorIs(tr.Rollback(bxs[0].Header.Hash), ErrUnknownBlock)
+
+	// No block can be rolled back with 0
+	tr.MaxRollback = 0
+	req.NoError(tr.ApplyBlock(bxs[2]))
+	assr.ErrorIs(tr.Rollback(bxs[1].Header.Hash), ErrUnknownBlock)
+	assr.Equal(bxs[2].Header.Hash, tr.LastBlock())
+}
@@ -0,0 +1,331 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	m "github.com/daotl/go-doubl/model"
+)
+
+var ErrCorrupted = errors.New("store file corrupted")
+
+// recordHeaderSize is the size of the header of every record, which consists of the length of the
+// record body as big endian uint64, the CRC-32C of the body, and the CRC-32C of the preceding 12
+// bytes of the header.
+const recordHeaderSize = 16
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// record is the location of an encoded block in the store file.
+type record struct {
+	offset int64
+	length int64
+}
+
+// encodeRecord returns the record of the block encoded as `bin`.
+func encodeRecord(bin []byte) []byte {
+	rec := make([]byte, recordHeaderSize+len(bin))
+	binary.BigEndian.PutUint64(rec, uint64(len(bin)))
+	binary.BigEndian.PutUint32(rec[8:], crc32.Checksum(bin, crcTable))
+	binary.BigEndian.PutUint32(rec[12:], crc32.Checksum(rec[:12], crcTable))
+	copy(rec[recordHeaderSize:], bin)
+	return rec
+}
+
+// FileStore is a Store backed by a single append-only file, in which every block is stored as a
+// record of the bytes written by BlockExt.WriteTo prefixed by a header with their length and
+// checksum.
+//
+// All blocks are reloaded when the file is opened to build the index, which is kept in memory
+// together with the block headers. Written blocks are synced to the disk by Sync and Close.
+type FileStore struct {
+	util    *m.Util
+	mtx     sync.RWMutex
+	ix      *index
+	file    *os.File
+	size    int64
+	records map[string]record
+	werr    error // set if the file is left with a partial record
+}
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFileStore opens or creates the FileStore at `path`, which reloads the stored blocks with `u`.
+//
+// A truncated record at the end of the file, which may be left by an interrupted write, is
+// discarded. A record is only considered truncated if its header is incomplete, or its header is
+// intact but its body extends past the end of the file. Any other invalid record makes it fail
+// with ErrCorrupted, and the file is left untouched.
+func OpenFileStore(u *m.Util, path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		util:    u,
+		ix:      newIndex(),
+		file:    f,
+		records: make(map[string]record),
+	}
+	if err = s.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads all records from the file and indexes the blocks.
+func (s *FileStore) load() error {
+	fi, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(s.file)
+	var hdr [recordHeaderSize]byte
+	var offset int64
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF {
+				break
+			} else if err == io.ErrUnexpectedEOF {
+				return s.truncate(offset)
+			}
+			return err
+		}
+		// The length can only be trusted after the header checksum is verified, a corrupted length
+		// could otherwise be taken for a truncated record and discard all the records after it.
+		if crc32.Checksum(hdr[:12], crcTable) != binary.BigEndian.Uint32(hdr[12:]) {
+			return fmt.Errorf("%w: record header checksum mismatch at %d", ErrCorrupted, offset)
+		}
+		length := binary.BigEndian.Uint64(hdr[:])
+		if length == 0 {
+			return fmt.Errorf("%w: empty record at %d", ErrCorrupted, offset)
+		}
+		if length > uint64(fi.Size()-offset-recordHeaderSize) {
+			return s.truncate(offset)
+		}
+		bin := make([]byte, length)
+		if _, err := io.ReadFull(r, bin); err != nil {
+			return err
+		}
+		if crc32.Checksum(bin, crcTable) != binary.BigEndian.Uint32(hdr[8:]) {
+			return fmt.Errorf("%w: record checksum mismatch at %d", ErrCorrupted, offset)
+		}
+		bx, err := decodeBlock(s.util, bin)
+		if err != nil {
+			return fmt.Errorf("%w: record at %d: %v", ErrCorrupted, offset, err)
+		}
+		if !s.ix.has(bx.Header.Hash) {
+			s.ix.add(bx)
+			s.records[string(bx.Header.Hash)] = record{
+				offset: offset + recordHeaderSize,
+				length: int64(length),
+			}
+		}
+		offset += recordHeaderSize + int64(length)
+	}
+	s.size = offset
+	return nil
+}
+
+// truncate discards the bytes after `offset` in the file.
+func (s *FileStore) truncate(offset int64) error {
+	if err := s.file.Truncate(offset); err != nil {
+		return err
+	}
+	s.size = offset
+	return nil
+}
+
+// PutBlock implements Store.
+func (s *FileStore) PutBlock(bx *m.BlockExt) error {
+	if err := checkBlock(bx); err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	if s.werr != nil {
+		return s.werr
+	}
+	if s.ix.has(bx.Header.Hash) {
+		return nil
+	}
+	bin, bx, err := encodeBlock(s.util, bx)
+	if err != nil {
+		return err
+	}
+	rec := encodeRecord(bin)
+	if _, err = s.file.WriteAt(rec, s.size); err != nil {
+		// Discard the partially written record, so the next write starts from a clean end. If it
+		// fails, the end of the file is unknown, and no more writes are accepted.
+		if err_ := s.file.Truncate(s.size); err_ != nil {
+			s.werr = fmt.Errorf("%w: failed to discard partial record at %d: %v", ErrCorrupted, s.size, err_)
+			return fmt.Errorf("%w (write: %v)", s.werr, err)
+		}
+		return err
+	}
+	s.records[string(bx.Header.Hash)] = record{
+		offset: s.size + recordHeaderSize,
+		length: int64(len(bin)),
+	}
+	s.size += int64(len(rec))
+	s.ix.add(bx)
+	return nil
+}
+
+// HasBlock implements Store.
+func (s *FileStore) HasBlock(hash m.BlockHash) (bool, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return false, ErrClosed
+	}
+	return s.ix.has(hash), nil
+}
+
+// Block implements Store.
+func (s *FileStore) Block(hash m.BlockHash) (*m.BlockExt, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+	return s.block(hash)
+}
+
+func (s *FileStore) block(hash m.BlockHash) (*m.BlockExt, error) {
+	rec, ok := s.records[string(hash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: block %x", ErrNotFound, hash)
+	}
+	bin := make([]byte, rec.length)
+	if _, err := s.file.ReadAt(bin, rec.offset); err != nil {
+		return nil, err
+	}
+	return decodeBlock(s.util, bin)
+}
+
+// Header implements dag.Lookup.
+func (s *FileStore) Header(hash m.BlockHash) (*m.BlockHeaderExt, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+	return s.ix.lookup.Header(hash)
+}
+
+// Children implements dag.Lookup.
+func (s *FileStore) Children(hash m.BlockHash) ([]m.BlockHash, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+	return s.ix.lookup.Children(hash)
+}
+
+// HeadersByHeight implements Store.
+func (s *FileStore) HeadersByHeight(height m.BlockHeight) ([]*m.BlockHeaderExt, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+	return s.ix.headersByHeight(height)
+}
+
+// Transaction implements Store.
+func (s *FileStore) Transaction(hash m.TransactionHash) (*m.TransactionExt, TxLocation, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return nil, TxLocation{}, ErrClosed
+	}
+	loc, err := s.ix.tx(hash)
+	if err != nil {
+		return nil, TxLocation{}, err
+	}
+	bx, err := s.block(loc.BlockHash)
+	if err != nil {
+		return nil, TxLocation{}, err
+	}
+	return bx.Txs[loc.Index], loc, nil
+}
+
+// Tips implements Store.
+func (s *FileStore) Tips() ([]m.BlockHash, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+	return s.ix.lookup.Tips(), nil
+}
+
+// Len implements Store.
+func (s *FileStore) Len() (int, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return 0, ErrClosed
+	}
+	return len(s.ix.order), nil
+}
+
+// ForEach implements Store.
+func (s *FileStore) ForEach(fn func(bx *m.BlockExt) error) error {
+	s.mtx.RLock()
+	if s.file == nil {
+		s.mtx.RUnlock()
+		return ErrClosed
+	}
+	order := s.ix.order
+	s.mtx.RUnlock()
+
+	// Blocks are never removed, so `fn` can be called without holding the lock.
+	for _, h := range order {
+		bx, err := s.Block(h)
+		if err != nil {
+			return err
+		}
+		if err = fn(bx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync commits the written blocks to the disk.
+func (s *FileStore) Sync() error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	return s.file.Sync()
+}
+
+// Close implements Store, it syncs the written blocks to the disk before closing the file.
+func (s *FileStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if err_ := s.file.Close(); err == nil {
+		err = err_
+	}
+	s.file = nil
+	return err
+}
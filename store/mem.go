@@ -0,0 +1,178 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+
+	m "github.com/daotl/go-doubl/model"
+)
+
+// MemStore is an in-memory Store.
+type MemStore struct {
+	util   *m.Util
+	mtx    sync.RWMutex
+	ix     *index
+	blocks map[string][]byte
+	closed bool
+}
+
+var _ Store = (*MemStore)(nil)
+
+// NewMemStore creates a new MemStore, which reloads the stored blocks with `u`.
+func NewMemStore(u *m.Util) *MemStore {
+	return &MemStore{
+		util:   u,
+		ix:     newIndex(),
+		blocks: make(map[string][]byte),
+	}
+}
+
+// PutBlock implements Store.
+func (s *MemStore) PutBlock(bx *m.BlockExt) error {
+	if err := checkBlock(bx); err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if s.ix.has(bx.Header.Hash) {
+		return nil
+	}
+	bin, bx, err := encodeBlock(s.util, bx)
+	if err != nil {
+		return err
+	}
+	s.blocks[string(bx.Header.Hash)] = bin
+	s.ix.add(bx)
+	return nil
+}
+
+// HasBlock implements Store.
+func (s *MemStore) HasBlock(hash m.BlockHash) (bool, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return false, ErrClosed
+	}
+	return s.ix.has(hash), nil
+}
+
+// Block implements Store.
+func (s *MemStore) Block(hash m.BlockHash) (*m.BlockExt, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return s.block(hash)
+}
+
+func (s *MemStore) block(hash m.BlockHash) (*m.BlockExt, error) {
+	bin, ok := s.blocks[string(hash)]
+	if !ok {
+		return nil, fmt.Errorf("%w: block %x", ErrNotFound, hash)
+	}
+	return decodeBlock(s.util, bin)
+}
+
+// Header implements dag.Lookup.
+func (s *MemStore) Header(hash m.BlockHash) (*m.BlockHeaderExt, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return s.ix.lookup.Header(hash)
+}
+
+// Children implements dag.Lookup.
+func (s *MemStore) Children(hash m.BlockHash) ([]m.BlockHash, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return s.ix.lookup.Children(hash)
+}
+
+// HeadersByHeight implements Store.
+func (s *MemStore) HeadersByHeight(height m.BlockHeight) ([]*m.BlockHeaderExt, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return s.ix.headersByHeight(height)
+}
+
+// Transaction implements Store.
+func (s *MemStore) Transaction(hash m.TransactionHash) (*m.TransactionExt, TxLocation, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, TxLocation{}, ErrClosed
+	}
+	loc, err := s.ix.tx(hash)
+	if err != nil {
+		return nil, TxLocation{}, err
+	}
+	bx, err := s.block(loc.BlockHash)
+	if err != nil {
+		return nil, TxLocation{}, err
+	}
+	return bx.Txs[loc.Index], loc, nil
+}
+
+// Tips implements Store.
+func (s *MemStore) Tips() ([]m.BlockHash, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return s.ix.lookup.Tips(), nil
+}
+
+// Len implements Store.
+func (s *MemStore) Len() (int, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	return len(s.ix.order), nil
+}
+
+// ForEach implements Store.
+func (s *MemStore) ForEach(fn func(bx *m.BlockExt) error) error {
+	s.mtx.RLock()
+	if s.closed {
+		s.mtx.RUnlock()
+		return ErrClosed
+	}
+	order := s.ix.order
+	s.mtx.RUnlock()
+
+	// Blocks are never removed, so `fn` can be called without holding the lock.
+	for _, h := range order {
+		bx, err := s.Block(h)
+		if err != nil {
+			return err
+		}
+		if err = fn(bx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements Store.
+func (s *MemStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.closed = true
+	s.blocks = nil
+	return nil
+}
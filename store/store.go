@@ -0,0 +1,173 @@
+// Package store provides storages of the blocks of a DOUBL ledger.
+//
+// Blocks are persisted as the bytes written by BlockExt.WriteTo verbatim, and reloaded through
+// Util.ReadBlockExtFrom.
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	cbg "github.com/daotl/cbor-gen"
+
+	"github.com/daotl/go-doubl/dag"
+	m "github.com/daotl/go-doubl/model"
+)
+
+// ErrNotFound is the same as dag.ErrNotFound, so that a Store satisfies dag.Lookup.
+var ErrNotFound = dag.ErrNotFound
+
+var (
+	ErrInvalidBlock = errors.New("invalid block")
+	ErrClosed       = errors.New("store closed")
+)
+
+// TxLocation is the location of a Transaction in the ledger.
+type TxLocation struct {
+	// Hash of the block containing the Transaction
+	BlockHash m.BlockHash
+
+	// Index of the Transaction in the block
+	Index int
+}
+
+// Store stores the blocks of a DOUBL ledger, implementations should be safe for concurrent use.
+//
+// A Store is also a dag.Lookup, so the dag utilities can be used with it directly.
+type Store interface {
+	dag.Lookup
+
+	// PutBlock stores `bx`, it's a no-op if a block with the same hash is already stored.
+	// The block is not validated, and its parents don't need to be stored first.
+	PutBlock(bx *m.BlockExt) error
+
+	// HasBlock reports whether the block with hash `hash` is stored.
+	HasBlock(hash m.BlockHash) (bool, error)
+
+	// Block returns the block with hash `hash`, or ErrNotFound if it's not stored.
+	Block(hash m.BlockHash) (*m.BlockExt, error)
+
+	// HeadersByHeight returns the headers of the stored blocks at `height`, sorted in ascending
+	// order of the hash bytes.
+	HeadersByHeight(height m.BlockHeight) ([]*m.BlockHeaderExt, error)
+
+	// Transaction returns the Transaction with hash `hash` and its location, or ErrNotFound if it's
+	// not in any stored block. If it's in multiple blocks, the one stored first is returned.
+	Transaction(hash m.TransactionHash) (*m.TransactionExt, TxLocation, error)
+
+	// Tips returns the hashes of the stored blocks which are not parents of any stored block,
+	// sorted in ascending order of the hash bytes.
+	Tips() ([]m.BlockHash, error)
+
+	// Len returns the number of the stored blocks.
+	Len() (int, error)
+
+	// ForEach calls `fn` with every stored block in the order they are stored, and stops at the
+	// first error returned by `fn`.
+	ForEach(fn func(bx *m.BlockExt) error) error
+
+	// Close closes the Store.
+	Close() error
+}
+
+// index indexes the stored blocks in memory, it's not safe for concurrent use.
+type index struct {
+	lookup  *dag.MemLookup
+	heights map[m.BlockHeight][]m.BlockHash
+	txs     map[string]TxLocation
+	order   []m.BlockHash
+}
+
+func newIndex() *index {
+	return &index{
+		lookup:  dag.NewMemLookup(),
+		heights: make(map[m.BlockHeight][]m.BlockHash),
+		txs:     make(map[string]TxLocation),
+	}
+}
+
+func (ix *index) has(hash m.BlockHash) bool {
+	_, err := ix.lookup.Header(hash)
+	return err == nil
+}
+
+func (ix *index) add(bx *m.BlockExt) {
+	bh := bx.Header
+	ix.lookup.Add(bh)
+	ix.heights[bh.Height] = append(ix.heights[bh.Height], bh.Hash)
+	for i, txx := range bx.Txs {
+		if _, ok := ix.txs[string(txx.Hash)]; !ok {
+			ix.txs[string(txx.Hash)] = TxLocation{BlockHash: bh.Hash, Index: i}
+		}
+	}
+	ix.order = append(ix.order, bh.Hash)
+}
+
+func (ix *index) headersByHeight(height m.BlockHeight) ([]*m.BlockHeaderExt, error) {
+	hs := ix.heights[height]
+	bhxs := make([]*m.BlockHeaderExt, len(hs))
+	for i, h := range hs {
+		var err error
+		if bhxs[i], err = ix.lookup.Header(h); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(bhxs, func(i, j int) bool { return bytes.Compare(bhxs[i].Hash, bhxs[j].Hash) < 0 })
+	return bhxs, nil
+}
+
+func (ix *index) tx(hash m.TransactionHash) (TxLocation, error) {
+	loc, ok := ix.txs[string(hash)]
+	if !ok {
+		return TxLocation{}, fmt.Errorf("%w: transaction %x", ErrNotFound, hash)
+	}
+	return loc, nil
+}
+
+// checkBlock checks that `bx` can be stored.
+func checkBlock(bx *m.BlockExt) error {
+	if bx == nil || bx.Header == nil || len(bx.Header.Hash) == 0 {
+		return fmt.Errorf("%w: no block hash", ErrInvalidBlock)
+	}
+	return nil
+}
+
+// encodeBlock returns the bytes that bx.WriteTo would write, encoding the Transactions with `u`,
+// and the block decoded back from them with `u`.
+//
+// The block is only stored if it can be reloaded with the same hash, and the decoded block is
+// indexed, so the index doesn't alias the memory of the caller.
+func encodeBlock(u *m.Util, bx *m.BlockExt) ([]byte, *m.BlockExt, error) {
+	var buf bytes.Buffer
+	buf.Write(m.BlockCborInitialBytes)
+	buf.Write(bx.Header.Bytes)
+	if len(bx.Txs) == 0 {
+		buf.Write(cbg.CborNull)
+	} else if _, err := u.WriteMarshalTransactionExtSliceTo(bx.Txs, &buf); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBlock, err)
+	}
+	bin := buf.Bytes()
+	decoded, err := decodeBlock(u, bin)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBlock, err)
+	}
+	if !bytes.Equal(decoded.Header.Hash, bx.Header.Hash) {
+		return nil, nil, fmt.Errorf("%w: block hash %x, decoded %x",
+			ErrInvalidBlock, bx.Header.Hash, decoded.Header.Hash)
+	}
+	return bin, decoded, nil
+}
+
+// decodeBlock reads the block encoded in `bin` with `u`.
+func decodeBlock(u *m.Util, bin []byte) (*m.BlockExt, error) {
+	bx, n, err := u.ReadBlockExtFrom(bytes.NewReader(bin))
+	if err != nil {
+		return nil, err
+	}
+	if n != int64(len(bin)) {
+		return nil, fmt.Errorf("%w: %d bytes", m.ErrTrailingBytes, int64(len(bin))-n)
+	}
+	return bx, nil
+}
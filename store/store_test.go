@@ -0,0 +1,268 @@
+package store_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daotl/go-doubl/dag"
+	m "github.com/daotl/go-doubl/model"
+	. "github.com/daotl/go-doubl/store"
+	"github.com/daotl/go-doubl/test"
+)
+
+func encode(t *testing.T, bx *m.BlockExt) []byte {
+	var buf bytes.Buffer
+	_, err := bx.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+// genBlocks generates blocks with the parent relations: b1 -> g, b2 -> g, b3 -> (b1, b2).
+func genBlocks() []*m.BlockExt {
+	g := test.GenSignedBlock(nil, 0, 0, 2)
+	b1 := test.GenSignedBlock([]m.BlockHash{g.Header.Hash}, 1, 2, 3)
+	b2 := test.GenSignedBlock([]m.BlockHash{g.Header.Hash}, 1, 5, 0)
+	b3 := test.GenSignedBlock([]m.BlockHash{b1.Header.Hash, b2.Header.Hash}, 2, 5, 1)
+	return []*m.BlockExt{g, b1, b2, b3}
+}
+
+func testStore(t *testing.T, s Store, bxs []*m.BlockExt) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	g, b1, b2, b3 := bxs[0], bxs[1], bxs[2], bxs[3]
+	n, err := s.Len()
+	req.NoError(err)
+	assr.Equal(len(bxs), n)
+
+	for _, bx := range bxs {
+		ok, err := s.HasBlock(bx.Header.Hash)
+		req.NoError(err)
+		assr.True(ok)
+		bx_, err := s.Block(bx.Header.Hash)
+		req.NoError(err)
+		assr.Equal(encode(t, bx), encode(t, bx_))
+		bhx, err := s.Header(bx.Header.Hash)
+		req.NoError(err)
+		assr.Equal(bx.Header.Bytes, bhx.Bytes)
+
+		for i, txx := range bx.Txs {
+			txx_, loc, err := s.Transaction(txx.Hash)
+			req.NoError(err)
+			assr.Equal(txx.Bytes, txx_.Bytes)
+			assr.Equal(TxLocation{BlockHash: bx.Header.Hash, Index: i}, loc)
+		}
+	}
+
+	ok, err := s.HasBlock(test.TestHash)
+	req.NoError(err)
+	assr.False(ok)
+	_, err = s.Block(test.TestHash)
+	assr.ErrorIs(err, ErrNotFound)
+	_, err = s.Header(test.TestHash)
+	assr.ErrorIs(err, ErrNotFound)
+	_, _, err = s.Transaction(test.TestHash)
+	assr.ErrorIs(err, ErrNotFound)
+
+	bhxs, err := s.HeadersByHeight(1)
+	req.NoError(err)
+	assr.ElementsMatch([][]byte{b1.Header.Bytes, b2.Header.Bytes}, [][]byte{bhxs[0].Bytes, bhxs[1].Bytes})
+	assr.Equal(-1, bytes.Compare(bhxs[0].Hash, bhxs[1].Hash))
+	bhxs, err = s.HeadersByHeight(3)
+	req.NoError(err)
+	assr.Empty(bhxs)
+
+	tips, err := s.Tips()
+	req.NoError(err)
+	assr.Equal([]m.BlockHash{b3.Header.Hash}, tips)
+	children, err := s.Children(g.Header.Hash)
+	req.NoError(err)
+	assr.ElementsMatch([]m.BlockHash{b1.Header.Hash, b2.Header.Hash}, children)
+
+	// A Store can be used as a dag.Lookup
+	ok, err = dag.IsAncestor(s, g.Header.Hash, b3.Header.Hash)
+	req.NoError(err)
+	assr.True(ok)
+
+	var hashes []m.BlockHash
+	req.NoError(s.ForEach(func(bx *m.BlockExt) error {
+		hashes = append(hashes, bx.Header.Hash)
+		return nil
+	}))
+	for i, bx := range bxs {
+		assr.Equal(bx.Header.Hash, hashes[i])
+	}
+	assr.ErrorIs(s.ForEach(func(bx *m.BlockExt) error { return ErrClosed }), ErrClosed)
+}
+
+func TestMemStore(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	bxs := genBlocks()
+	s := NewMemStore(test.Util)
+	// Children can be stored before parents
+	for i := len(bxs) - 1; i >= 0; i-- {
+		req.NoError(s.PutBlock(bxs[i]))
+		req.NoError(s.PutBlock(bxs[i]))
+	}
+	assr.ErrorIs(s.PutBlock(&m.BlockExt{}), ErrInvalidBlock)
+
+	tips, err := s.Tips()
+	req.NoError(err)
+	assr.Equal([]m.BlockHash{bxs[3].Header.Hash}, tips)
+	i := len(bxs)
+	req.NoError(s.ForEach(func(bx *m.BlockExt) error {
+		i--
+		assr.Equal(bxs[i].Header.Hash, bx.Header.Hash)
+		return nil
+	}))
+	assr.Equal(0, i)
+
+	s = NewMemStore(test.Util)
+	for _, bx := range bxs {
+		req.NoError(s.PutBlock(bx))
+	}
+	testStore(t, s, bxs)
+
+	req.NoError(s.Close())
+	_, err = s.Block(bxs[0].Header.Hash)
+	assr.ErrorIs(err, ErrClosed)
+	assr.ErrorIs(s.PutBlock(bxs[0]), ErrClosed)
+}
+
+func TestFileStore(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "blocks")
+	bxs := genBlocks()
+	s, err := OpenFileStore(test.Util, path)
+	req.NoError(err)
+	for _, bx := range bxs {
+		req.NoError(s.PutBlock(bx))
+		req.NoError(s.PutBlock(bx))
+	}
+	testStore(t, s, bxs)
+	req.NoError(s.Close())
+	req.NoError(s.Close())
+	_, err = s.Block(bxs[0].Header.Hash)
+	assr.ErrorIs(err, ErrClosed)
+
+	// The blocks are stored verbatim
+	bin, err := os.ReadFile(path)
+	req.NoError(err)
+	size := 0
+	for _, bx := range bxs {
+		enc := encode(t, bx)
+		assr.Equal(enc, bin[size+16:size+16+len(enc)])
+		size += 16 + len(enc)
+	}
+	assr.Equal(size, len(bin))
+
+	t.Run("Reopen", func(t *testing.T) {
+		s, err := OpenFileStore(test.Util, path)
+		req.NoError(err)
+		defer s.Close()
+		testStore(t, s, bxs)
+
+		b4 := test.GenSignedBlock([]m.BlockHash{bxs[3].Header.Hash}, 3, 6, 1)
+		req.NoError(s.PutBlock(b4))
+		req.NoError(s.Close())
+		s, err = OpenFileStore(test.Util, path)
+		req.NoError(err)
+		n, err := s.Len()
+		req.NoError(err)
+		assr.Equal(5, n)
+		tips, err := s.Tips()
+		req.NoError(err)
+		assr.Equal([]m.BlockHash{b4.Header.Hash}, tips)
+		req.NoError(s.Close())
+		req.NoError(os.WriteFile(path, bin, 0o644))
+	})
+
+	t.Run("Truncated record", func(t *testing.T) {
+		for _, cut := range []int{1, 16, len(encode(t, bxs[3])) + 15} {
+			req.NoError(os.WriteFile(path, bin[:len(bin)-cut], 0o644))
+			s, err := OpenFileStore(test.Util, path)
+			req.NoError(err)
+			n, err := s.Len()
+			req.NoError(err)
+			assr.Equal(3, n)
+			// Writes continue from the last complete record
+			req.NoError(s.PutBlock(bxs[3]))
+			req.NoError(s.Close())
+			bin_, err := os.ReadFile(path)
+			req.NoError(err)
+			assr.Equal(bin, bin_)
+		}
+	})
+
+	t.Run("Corrupted record", func(t *testing.T) {
+		first := 16 + len(encode(t, bxs[0]))
+		// A corrupted length in the middle of the file must not be taken for a truncated record
+		for _, i := range []int{0, 7, 9, 15, first - 1, first + 3} {
+			corrupted := append([]byte{}, bin...)
+			corrupted[i]++
+			req.NoError(os.WriteFile(path, corrupted, 0o644))
+			_, err := OpenFileStore(test.Util, path)
+			assr.ErrorIs(err, ErrCorrupted)
+			bin_, err := os.ReadFile(path)
+			req.NoError(err)
+			assr.Equal(corrupted, bin_)
+		}
+	})
+}
+
+func TestPutInvalidBlock(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "blocks")
+	fs, err := OpenFileStore(test.Util, path)
+	req.NoError(err)
+	for _, s := range []Store{NewMemStore(test.Util), fs} {
+		bx := test.GenSignedBlock(nil, 0, 0, 2)
+		header := func() *m.BlockHeaderExt {
+			return &m.BlockHeaderExt{
+				BlockHeader: bx.Header.BlockHeader,
+				Bytes:       bx.Header.Bytes,
+				Hash:        bx.Header.Hash,
+			}
+		}
+		noBytes := header()
+		noBytes.Bytes = nil
+		assr.ErrorIs(s.PutBlock(&m.BlockExt{Header: noBytes, Txs: bx.Txs}), ErrInvalidBlock)
+		wrongHash := header()
+		wrongHash.Hash = test.TestHash
+		assr.ErrorIs(s.PutBlock(&m.BlockExt{Header: wrongHash, Txs: bx.Txs}), ErrInvalidBlock)
+		n, err := s.Len()
+		req.NoError(err)
+		assr.Equal(0, n)
+
+		// A block not created through a Util can be stored, and the Store doesn't keep the header
+		// of the caller.
+		req.NoError(s.PutBlock(&m.BlockExt{Header: header(), Txs: bx.Txs}))
+		bx_, err := s.Block(bx.Header.Hash)
+		req.NoError(err)
+		assr.Equal(encode(t, bx), encode(t, bx_))
+		bx.Header.Height = 10
+		stored, err := s.Header(bx.Header.Hash)
+		req.NoError(err)
+		assr.Equal(m.BlockHeight(0), stored.Height)
+		req.NoError(s.Close())
+	}
+
+	// The file is still valid
+	fs, err = OpenFileStore(test.Util, path)
+	req.NoError(err)
+	n, err := fs.Len()
+	req.NoError(err)
+	assr.Equal(1, n)
+	req.NoError(fs.Close())
+}
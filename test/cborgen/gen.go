@@ -13,6 +13,7 @@ func main() {
 		true,
 		nil,
 		test.TransactionNoSig{},
+		test.TestExtra{},
 	); err != nil {
 		panic(err)
 	}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"strconv"
 	"time"
+	"unsafe"
 
 	"github.com/crpt/go-crpt"
 	"github.com/daotl/go-marsha"
@@ -33,6 +34,16 @@ type TransactionNoSig struct {
 func (t TransactionNoSig) Ptr() marsha.StructPtr { return &t }
 func (p *TransactionNoSig) Val() marsha.Struct   { return *p }
 
+// TestExtra is a model.ExtraPtr implementation for test.
+type TestExtra struct {
+	Key   uint64
+	Value []byte
+}
+
+func (t TestExtra) Ptr() marsha.StructPtr { return &t }
+func (p *TestExtra) Val() marsha.Struct   { return *p }
+func (p *TestExtra) Size() uint64         { return uint64(unsafe.Sizeof(*p)) + uint64(len(p.Value)) }
+
 var (
 	ut *m.Util
 
@@ -77,16 +88,11 @@ func GenTestModels() {
 		Sig:   nil,
 	}
 
-	bin, err := ut.Mrsh.MarshalStruct(&TestTransaction)
-	if err != nil {
-		panic(err)
-	}
-
-	TestTransaction.Sig, err = TestPrivateKey.SignMessage(bin, nil)
-	if err != nil {
+	if err := ut.SignTransaction(&TestTransaction, TestPrivateKey); err != nil {
 		panic(err)
 	}
 
+	var err error
 	TestTransactionHash, err = ut.HashTransaction(&TestTransaction)
 	if err != nil {
 		panic(err)
@@ -95,6 +101,9 @@ func GenTestModels() {
 	TestTransactionSlice = m.TransactionSlice{TestTransaction, TestTransaction}
 
 	TestBlockHeader = *GenTestBlockHeaderWithExtra([]byte{0x4, 0x13, 0x52})
+	if err := ut.SignBlockHeader(&TestBlockHeader, TestPrivateKey); err != nil {
+		panic(err)
+	}
 
 	TestBlock = m.Block{
 		Header: &TestBlockHeader,
@@ -213,6 +222,44 @@ func GenRandomBlocks(min, max, prevHashCount int, extraBytes []byte, txCount int
 	return bxs
 }
 
+// GenSignedTransaction generates a random Transaction with the given nonce signed by TestPrivateKey.
+func GenSignedTransaction(nonce uint64) *m.Transaction {
+	tx := GenRandomTransaction()
+	tx.Nonce = nonce
+	if err := ut.SignTransaction(tx, TestPrivateKey); err != nil {
+		panic(err)
+	}
+	return tx
+}
+
+// GenSignedBlock creates a valid Block for test with the given previous block hashes and height,
+// which contains `txCount` Transactions with consecutive nonces starting from `nonce`.
+// The Block and Transactions are signed by TestPrivateKey.
+func GenSignedBlock(prevHashes []m.BlockHash, height m.BlockHeight, nonce uint64, txCount int,
+) *m.BlockExt {
+	bh := GenTestBlockHeaderWithExtra(nil)
+	bh.PrevHashes = prevHashes
+	bh.Height = height
+	bh.TxCount = uint64(txCount)
+	txs := make(m.TransactionSlice, txCount)
+	for i := 0; i < txCount; i++ {
+		txs[i] = *GenSignedTransaction(nonce + uint64(i))
+	}
+	var err error
+	if bh.TxRoot, err = ut.GenRootHashFromTransactionSlice(txs); err != nil {
+		panic(err)
+	}
+	if err = ut.SignBlockHeader(bh, TestPrivateKey); err != nil {
+		panic(err)
+	}
+
+	bx, err := ut.ExtendBlock(&m.Block{Header: bh, Txs: txs})
+	if err != nil {
+		panic(err)
+	}
+	return bx
+}
+
 // GenRandomHash generates a random hash for test.
 func GenRandomHash() m.Hash32 {
 	a := [m.HashSize]byte{}
@@ -272,3 +272,113 @@ func (t *TransactionNoSig) UnmarshalCBOR(r io.Reader) (int, error) {
 	}
 	return bytesRead, nil
 }
+
+func (t *TestExtra) InitNilEmbeddedStruct() {
+	if t != nil {
+	}
+}
+
+var lengthBufTestExtra = []byte{130}
+
+func (t *TestExtra) MarshalCBOR(w io.Writer) (n int, err error) {
+	if t == nil {
+		return w.Write(cbg.CborNull)
+	}
+	t.InitNilEmbeddedStruct()
+	if n_, err := w.Write(lengthBufTestExtra); err != nil {
+		return n_, err
+	} else {
+		n += n_
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Key (uint64) (uint64)
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Key)); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	// t.Value ([]uint8) (slice)
+	if len(t.Value) > cbg.ByteArrayMaxLen {
+		return n, xerrors.Errorf("Byte array in field t.Value was too long")
+	}
+
+	if n_, err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.Value))); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+
+	if n_, err := w.Write(t.Value[:]); err != nil {
+		return n + n_, err
+	} else {
+		n += n_
+	}
+	return n, nil
+}
+
+func (t *TestExtra) UnmarshalCBOR(r io.Reader) (int, error) {
+	bytesRead := 0
+	*t = TestExtra{}
+	t.InitNilEmbeddedStruct()
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, read, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+	if maj != cbg.MajArray {
+		return bytesRead, fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return bytesRead, fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Key (uint64) (uint64)
+
+	{
+
+		maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return bytesRead, err
+		}
+		bytesRead += read
+		if maj != cbg.MajUnsignedInt {
+			return bytesRead, fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Key = uint64(extra)
+
+	}
+	// t.Value ([]uint8) (slice)
+
+	maj, extra, read, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return bytesRead, err
+	}
+	bytesRead += read
+
+	if extra > cbg.ByteArrayMaxLen {
+		return bytesRead, fmt.Errorf("t.Value: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return bytesRead, fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.Value = make([]uint8, extra)
+	}
+
+	if read, err := io.ReadFull(br, t.Value[:]); err != nil {
+		return bytesRead, err
+	} else {
+		bytesRead += read
+	}
+	return bytesRead, nil
+}
@@ -0,0 +1,221 @@
+// Package txindex indexes the Transactions of a DOUBL ledger by hash, sender and recipient.
+package txindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/daotl/go-doubl/dag"
+	m "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/store"
+)
+
+// ErrNotFound is the same as store.ErrNotFound.
+var ErrNotFound = store.ErrNotFound
+
+var (
+	ErrInvalidBlock  = errors.New("invalid block")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SeqSize is the size of an encoded Seq.
+const SeqSize = 8
+
+// Seq is the sequence number of a Transaction in an Indexer, which increases in the order the
+// Transactions are indexed.
+type Seq uint64
+
+// Encode encodes a Seq as big endian uint64, so that it can be used as storage key and retain the
+// order, see BlockHeight.Encode.
+func (s Seq) Encode() []byte {
+	enc := make([]byte, SeqSize)
+	binary.BigEndian.PutUint64(enc, uint64(s))
+	return enc
+}
+
+// DecodeSeq decodes Seq from big endian encoded bytes.
+func DecodeSeq(bin []byte) Seq {
+	return Seq(binary.BigEndian.Uint64(bin))
+}
+
+// AddressKey encodes the storage key of the Transaction with `seq` in the Transaction list of
+// `addr`, which is `addr` followed by the encoded `seq`, so the keys of the Transactions of an
+// address are ordered by Seq.
+func AddressKey(addr m.Address, seq Seq) []byte {
+	key := make([]byte, 0, len(addr)+SeqSize)
+	return append(append(key, addr...), seq.Encode()...)
+}
+
+// Entry is an indexed Transaction.
+type Entry struct {
+	// Sequence number of the Transaction in the Indexer
+	Seq Seq
+
+	// Hash of the Transaction
+	Hash m.TransactionHash
+
+	// Location of the Transaction in the ledger
+	Location store.TxLocation
+}
+
+// Indexer indexes the Transactions in the consumed blocks by hash, and the Transactions from and
+// to every address in the order they are indexed. It's safe for concurrent use.
+//
+// Blocks should be consumed in the execution order (e.g., the order of a dag.Linearizer), so that
+// the Transaction lists of the addresses are in the execution order too. A Transaction included by
+// multiple blocks is only indexed in the first one consumed. Consumed blocks can't be removed, so
+// the Indexer should be rebuilt if the execution order changes, see Rebuild.
+type Indexer struct {
+	mtx    sync.RWMutex
+	seq    Seq
+	blocks map[string]struct{}
+	hashes map[string]Entry
+	from   map[string][]Entry
+	to     map[string][]Entry
+}
+
+// New creates a new empty Indexer.
+func New() *Indexer {
+	return &Indexer{
+		blocks: make(map[string]struct{}),
+		hashes: make(map[string]Entry),
+		from:   make(map[string][]Entry),
+		to:     make(map[string][]Entry),
+	}
+}
+
+// FromStore creates a new Indexer with the blocks in `s` reachable from `tip` consumed in linear
+// order, see Rebuild.
+func FromStore(s store.Store, tip m.BlockHash) (*Indexer, error) {
+	ix := New()
+	if err := ix.Rebuild(s, tip); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// Rebuild resets the Indexer and consumes the blocks in `s` reachable from `tip` in the order of
+// dag.Linearize, regardless of the order they are stored. The Indexer is not changed if an error
+// is returned.
+//
+// The Seqs and cursors obtained before are invalidated if the blocks were consumed in another
+// order, e.g., after dag.Linearizer.Extend reorders blocks.
+func (ix *Indexer) Rebuild(s store.Store, tip m.BlockHash) error {
+	order, err := dag.Linearize(s, tip)
+	if err != nil {
+		return err
+	}
+	rebuilt := New()
+	for _, bhx := range order {
+		bx, err := s.Block(bhx.Hash)
+		if err != nil {
+			return err
+		}
+		if err = rebuilt.IndexBlock(bx); err != nil {
+			return err
+		}
+	}
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	ix.seq, ix.blocks, ix.hashes, ix.from, ix.to =
+		rebuilt.seq, rebuilt.blocks, rebuilt.hashes, rebuilt.from, rebuilt.to
+	return nil
+}
+
+// IndexBlock consumes `bx` and indexes its Transactions, it's a no-op if `bx` has been consumed.
+func (ix *Indexer) IndexBlock(bx *m.BlockExt) error {
+	if bx == nil || bx.Header == nil || len(bx.Header.Hash) == 0 {
+		return fmt.Errorf("%w: no block hash", ErrInvalidBlock)
+	}
+	ix.mtx.Lock()
+	defer ix.mtx.Unlock()
+	if _, ok := ix.blocks[string(bx.Header.Hash)]; ok {
+		return nil
+	}
+	ix.blocks[string(bx.Header.Hash)] = struct{}{}
+	for i, txx := range bx.Txs {
+		if _, ok := ix.hashes[string(txx.Hash)]; ok {
+			continue
+		}
+		e := Entry{
+			Seq:      ix.seq,
+			Hash:     txx.Hash,
+			Location: store.TxLocation{BlockHash: bx.Header.Hash, Index: i},
+		}
+		ix.seq++
+		ix.hashes[string(txx.Hash)] = e
+		if len(txx.From) > 0 {
+			ix.from[string(txx.From)] = append(ix.from[string(txx.From)], e)
+		}
+		if len(txx.To) > 0 {
+			ix.to[string(txx.To)] = append(ix.to[string(txx.To)], e)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the Entry of the Transaction with hash `hash`, or ErrNotFound if it's not indexed.
+func (ix *Indexer) Lookup(hash m.TransactionHash) (Entry, error) {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+	e, ok := ix.hashes[string(hash)]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: transaction %x", ErrNotFound, hash)
+	}
+	return e, nil
+}
+
+// From returns a page of the Transactions from `addr` in the order they are indexed, starting after
+// `cursor` and containing at most `limit` Transactions, and the cursor of the next page.
+// A nil `cursor` starts from the first Transaction, a non-positive `limit` means no limit, and the
+// returned cursor is nil if there are no more Transactions.
+func (ix *Indexer) From(addr m.Address, cursor []byte, limit int) ([]Entry, []byte, error) {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+	return page(addr, ix.from[string(addr)], cursor, limit)
+}
+
+// To returns a page of the Transactions to `addr` in the order they are indexed, see From.
+func (ix *Indexer) To(addr m.Address, cursor []byte, limit int) ([]Entry, []byte, error) {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+	return page(addr, ix.to[string(addr)], cursor, limit)
+}
+
+// Len returns the number of the indexed Transactions.
+func (ix *Indexer) Len() int {
+	ix.mtx.RLock()
+	defer ix.mtx.RUnlock()
+	return len(ix.hashes)
+}
+
+// page returns at most `limit` entries in `entries` of `addr` after `cursor`, and the cursor of the
+// next page which is nil if there are no more entries. A nil `cursor` starts from the first entry,
+// and a non-positive `limit` means no limit.
+//
+// The cursor is the AddressKey of the last returned Entry, so it remains valid after more blocks
+// are consumed.
+func page(addr m.Address, entries []Entry, cursor []byte, limit int) ([]Entry, []byte, error) {
+	start := 0
+	if cursor != nil {
+		if len(cursor) != len(addr)+SeqSize || !bytes.Equal(cursor[:len(addr)], addr) {
+			return nil, nil, fmt.Errorf("%w: %x", ErrInvalidCursor, cursor)
+		}
+		after := DecodeSeq(cursor[len(addr):])
+		start = sort.Search(len(entries), func(i int) bool { return entries[i].Seq > after })
+	}
+	end := len(entries)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	result := append([]Entry(nil), entries[start:end]...)
+	var next []byte
+	if end < len(entries) {
+		next = AddressKey(addr, entries[end-1].Seq)
+	}
+	return result, next, nil
+}
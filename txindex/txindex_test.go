@@ -0,0 +1,172 @@
+package txindex_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daotl/go-doubl/dag"
+	m "github.com/daotl/go-doubl/model"
+	"github.com/daotl/go-doubl/store"
+	"github.com/daotl/go-doubl/test"
+	. "github.com/daotl/go-doubl/txindex"
+)
+
+var addr3 = m.Address(test.TestHash2)
+
+// genBlock creates a block with the given parents and Transactions from and to the given addresses
+// in turn.
+func genBlock(from, to []m.Address, parents ...*m.BlockExt) *m.BlockExt {
+	bh := test.GenTestBlockHeaderWithExtra(nil)
+	bh.TxRoot = test.GenRandomHash()
+	bh.PrevHashes, bh.Height = nil, 0
+	for _, p := range parents {
+		bh.PrevHashes = append(bh.PrevHashes, p.Header.Hash)
+		if p.Header.Height >= bh.Height {
+			bh.Height = p.Header.Height + 1
+		}
+	}
+	txs := make(m.TransactionSlice, len(from))
+	for i := range from {
+		tx := test.GenRandomTransaction()
+		tx.From, tx.To = from[i], to[i]
+		txs[i] = *tx
+	}
+	bh.TxCount = uint64(len(txs))
+	bx, err := test.Util.ExtendBlock(&m.Block{Header: bh, Txs: txs})
+	if err != nil {
+		panic(err)
+	}
+	return bx
+}
+
+func hashes(es []Entry) []m.TransactionHash {
+	hs := make([]m.TransactionHash, len(es))
+	for i, e := range es {
+		hs[i] = e.Hash
+	}
+	return hs
+}
+
+func TestIndexer(t *testing.T) {
+	req := require.New(t)
+	assr := assert.New(t)
+
+	a, b := test.TestAddress, test.TestAddress2
+	b1 := genBlock([]m.Address{a, b, a}, []m.Address{b, a, addr3})
+	b2 := genBlock([]m.Address{a, addr3}, []m.Address{nil, b})
+	ix := New()
+	req.NoError(ix.IndexBlock(b1))
+	req.NoError(ix.IndexBlock(b2))
+	req.NoError(ix.IndexBlock(b1))
+	assr.ErrorIs(ix.IndexBlock(&m.BlockExt{}), ErrInvalidBlock)
+	assr.Equal(5, ix.Len())
+
+	for i, txx := range b2.Txs {
+		e, err := ix.Lookup(txx.Hash)
+		req.NoError(err)
+		assr.Equal(Seq(3+i), e.Seq)
+		assr.Equal(txx.Hash, e.Hash)
+		assr.Equal(store.TxLocation{BlockHash: b2.Header.Hash, Index: i}, e.Location)
+	}
+	_, err := ix.Lookup(test.TestHash)
+	assr.ErrorIs(err, ErrNotFound)
+
+	es, next, err := ix.From(a, nil, 0)
+	req.NoError(err)
+	assr.Nil(next)
+	assr.Equal([]m.TransactionHash{b1.Txs[0].Hash, b1.Txs[2].Hash, b2.Txs[0].Hash}, hashes(es))
+	es, _, err = ix.To(b, nil, 0)
+	req.NoError(err)
+	assr.Equal([]m.TransactionHash{b1.Txs[0].Hash, b2.Txs[1].Hash}, hashes(es))
+	es, _, err = ix.To(test.GenRandomHash(), nil, 10)
+	req.NoError(err)
+	assr.Empty(es)
+
+	t.Run("Pagination", func(t *testing.T) {
+		es, next, err := ix.From(a, nil, 2)
+		req.NoError(err)
+		assr.Equal([]m.TransactionHash{b1.Txs[0].Hash, b1.Txs[2].Hash}, hashes(es))
+		assr.Equal(AddressKey(a, 2), next)
+
+		// The cursor remains valid after more blocks are indexed
+		b3 := genBlock([]m.Address{a}, []m.Address{b})
+		ix := New()
+		req.NoError(ix.IndexBlock(b1))
+		req.NoError(ix.IndexBlock(b2))
+		req.NoError(ix.IndexBlock(b3))
+		es, next, err = ix.From(a, next, 1)
+		req.NoError(err)
+		assr.Equal([]m.TransactionHash{b2.Txs[0].Hash}, hashes(es))
+		es, next, err = ix.From(a, next, 1)
+		req.NoError(err)
+		assr.Equal([]m.TransactionHash{b3.Txs[0].Hash}, hashes(es))
+		assr.Nil(next)
+
+		_, _, err = ix.From(a, AddressKey(b, 0), 1)
+		assr.ErrorIs(err, ErrInvalidCursor)
+		_, _, err = ix.From(a, []byte{1}, 1)
+		assr.ErrorIs(err, ErrInvalidCursor)
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		// Blocks are stored in reverse of the linear order g, x1, x2, tip or g, x2, x1, tip
+		g := genBlock([]m.Address{a}, []m.Address{b})
+		x1 := genBlock([]m.Address{a, b}, []m.Address{b, a}, g)
+		x2 := genBlock([]m.Address{a}, []m.Address{addr3}, g)
+		tip := genBlock([]m.Address{b}, []m.Address{a}, x1, x2)
+		s := store.NewMemStore(test.Util)
+		for _, bx := range []*m.BlockExt{tip, x2, x1, g} {
+			req.NoError(s.PutBlock(bx))
+		}
+
+		order, err := dag.Linearize(s, tip.Header.Hash)
+		req.NoError(err)
+		ix := New()
+		for _, bhx := range order {
+			bx, err := s.Block(bhx.Hash)
+			req.NoError(err)
+			req.NoError(ix.IndexBlock(bx))
+		}
+		rebuilt, err := FromStore(s, tip.Header.Hash)
+		req.NoError(err)
+		assr.Equal(ix.Len(), rebuilt.Len())
+		for _, addr := range []m.Address{a, b, addr3} {
+			es, _, err := ix.From(addr, nil, 0)
+			req.NoError(err)
+			es_, _, err := rebuilt.From(addr, nil, 0)
+			req.NoError(err)
+			assr.Equal(es, es_)
+			es, _, err = ix.To(addr, nil, 0)
+			req.NoError(err)
+			es_, _, err = rebuilt.To(addr, nil, 0)
+			req.NoError(err)
+			assr.Equal(es, es_)
+		}
+		es, _, err := rebuilt.From(a, nil, 0)
+		req.NoError(err)
+		assr.Equal(g.Txs[0].Hash, es[0].Hash)
+
+		// Only the blocks reachable from the tip are consumed
+		rebuilt, err = FromStore(s, x2.Header.Hash)
+		req.NoError(err)
+		assr.Equal(len(g.Txs)+len(x2.Txs), rebuilt.Len())
+
+		assr.ErrorIs(rebuilt.Rebuild(s, test.TestHash), ErrNotFound)
+		req.NoError(s.Close())
+		assr.ErrorIs(rebuilt.Rebuild(s, tip.Header.Hash), store.ErrClosed)
+		assr.Equal(len(g.Txs)+len(x2.Txs), rebuilt.Len())
+	})
+}
+
+func TestKeys(t *testing.T) {
+	assr := assert.New(t)
+
+	assr.Equal([]byte{0, 0, 0, 0, 0, 0, 1, 2}, Seq(0x102).Encode())
+	assr.Equal(Seq(0x102), DecodeSeq(Seq(0x102).Encode()))
+	key := AddressKey(test.TestAddress, 0x102)
+	assr.Equal(test.TestAddress, m.Address(key[:len(test.TestAddress)]))
+	assr.Equal(Seq(0x102).Encode(), key[len(test.TestAddress):])
+	assr.Less(string(AddressKey(test.TestAddress, 0xff)), string(AddressKey(test.TestAddress, 0x100)))
+}